@@ -0,0 +1,680 @@
+package main
+
+import (
+	"database/sql"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/auth"
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/database"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	defaultAPIPostLimit = 20
+	maxAPIPostLimit     = 100
+
+	// Request bodies are small JSON objects
+	maxAPIBodySize = 1 << 20
+)
+
+//go:embed openapi.yaml
+var openAPISpec []byte
+
+// apiServer serves the JSON REST API of "gator serve". Every handler works on
+// behalf of the user owning the API key of the request.
+type apiServer struct {
+	state *state
+}
+
+type apiHandler func(w http.ResponseWriter, r *http.Request, user database.User)
+
+type apiError struct {
+	Error string `json:"error"`
+}
+
+type apiUser struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type apiFeed struct {
+	ID       uuid.UUID `json:"id"`
+	Name     string    `json:"name"`
+	Url      string    `json:"url"`
+	UserName string    `json:"user_name"`
+}
+
+type apiFollow struct {
+	FeedID      uuid.UUID `json:"feed_id"`
+	FeedName    string    `json:"feed_name"`
+	FeedUrl     string    `json:"feed_url"`
+	SiteUrl     string    `json:"site_url"`
+	UnreadCount int64     `json:"unread_count"`
+}
+
+type apiPost struct {
+	ID          uuid.UUID  `json:"id"`
+	FeedID      uuid.UUID  `json:"feed_id"`
+	FeedName    string     `json:"feed_name"`
+	Title       string     `json:"title"`
+	Url         string     `json:"url"`
+	Author      string     `json:"author"`
+	Description string     `json:"description"`
+	Content     string     `json:"content"`
+	Categories  []string   `json:"categories"`
+	Tags        []string   `json:"tags"`
+	PublishedAt *time.Time `json:"published_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	IsRead      bool       `json:"is_read"`
+	IsStarred   bool       `json:"is_starred"`
+	IsFiltered  bool       `json:"is_filtered"`
+}
+
+type apiPostPage struct {
+	Posts      []apiPost `json:"posts"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
+
+// newServer routes the requests of "gator serve": the JSON API, the river
+// feeds and the web UI.
+func newServer(s *state) http.Handler {
+	api := &apiServer{state: s}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/openapi.yaml", handleOpenAPISpec)
+	mux.Handle("GET /api/me", api.authenticated(api.handleMe))
+	mux.Handle("GET /api/users", api.authenticated(api.handleUsers))
+	mux.Handle("GET /api/feeds", api.authenticated(api.handleFeeds))
+	mux.Handle("POST /api/feeds", api.authenticated(api.handleCreateFeed))
+	mux.Handle("GET /api/follows", api.authenticated(api.handleFollows))
+	mux.Handle("POST /api/follows", api.authenticated(api.handleCreateFollow))
+	mux.Handle("DELETE /api/follows/{feedID}", api.authenticated(api.handleDeleteFollow))
+	mux.Handle("GET /api/posts", api.authenticated(api.handlePosts))
+	mux.Handle("PUT /api/posts/{postID}/read", api.authenticated(api.handleMarkRead))
+	mux.Handle("DELETE /api/posts/{postID}/read", api.authenticated(api.handleMarkUnread))
+	mux.Handle("PUT /api/posts/{postID}/star", api.authenticated(api.handleStar))
+	mux.Handle("DELETE /api/posts/{postID}/star", api.authenticated(api.handleUnstar))
+	mux.HandleFunc("GET /river/{token}/rss.xml", api.handleRiverFeed(riverFormatRSS))
+	mux.HandleFunc("GET /river/{token}/atom.xml", api.handleRiverFeed(riverFormatAtom))
+
+	newWebServer(s).register(mux)
+
+	return mux
+}
+
+func respondWithJSON(w http.ResponseWriter, code int, payload any) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		slog.Error("failed to encode response", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(data)
+}
+
+func respondWithError(w http.ResponseWriter, code int, message string) {
+	respondWithJSON(w, code, apiError{Error: message})
+}
+
+// respondWithInternalError logs the cause of a server error, clients only get
+// a generic message.
+func respondWithInternalError(w http.ResponseWriter, message string, err error) {
+	slog.Error(message, "error", err)
+	respondWithError(w, http.StatusInternalServerError, message)
+}
+
+// decodeJSONBody decodes a request body of at most maxAPIBodySize bytes. It
+// responds with an error and returns false if the body cannot be used.
+func decodeJSONBody(w http.ResponseWriter, r *http.Request, payload any) bool {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAPIBodySize))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(payload); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondWithError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("the request body is larger than %v bytes", maxBytesErr.Limit))
+			return false
+		}
+
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return false
+	}
+
+	return true
+}
+
+// authenticated resolves the user from the "Authorization: Bearer <key>"
+// header, it is the API counterpart of middlewareLoggedIn. Read-only keys are
+// limited to GET requests.
+func (api *apiServer) authenticated(handler apiHandler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !found || key == "" {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			respondWithError(w, http.StatusUnauthorized, "missing API key")
+			return
+		}
+
+		keyHash := auth.HashAPIKey(key)
+		now := time.Now()
+
+		user, err := api.state.database.FindUserByAPIKey(r.Context(), database.FindUserByAPIKeyParams{
+			KeyHash: keyHash,
+			Now:     now,
+		})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				respondWithError(w, http.StatusUnauthorized, "invalid or expired API key")
+				return
+			}
+
+			respondWithInternalError(w, "failed to check API key", err)
+			return
+		}
+
+		scope, err := api.state.database.TouchAPIKey(r.Context(), database.TouchAPIKeyParams{
+			LastUsedAt: sql.NullTime{Time: now, Valid: true},
+			KeyHash:    keyHash,
+		})
+		if err != nil {
+			respondWithInternalError(w, "failed to update API key", err)
+			return
+		}
+
+		if scope != apiKeyScopeReadWrite && r.Method != http.MethodGet && r.Method != http.MethodHead {
+			respondWithError(w, http.StatusForbidden, ErrAPIKeyReadOnly.Error())
+			return
+		}
+
+		handler(w, r, user)
+	})
+}
+
+func handleOpenAPISpec(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/yaml")
+	w.Write(openAPISpec)
+}
+
+func apiUserFromUser(user database.User) apiUser {
+	return apiUser{
+		ID:        user.ID,
+		Name:      user.Name,
+		Role:      user.Role,
+		CreatedAt: user.CreatedAt,
+	}
+}
+
+func (api *apiServer) handleMe(w http.ResponseWriter, r *http.Request, user database.User) {
+	respondWithJSON(w, http.StatusOK, apiUserFromUser(user))
+}
+
+func (api *apiServer) handleUsers(w http.ResponseWriter, r *http.Request, user database.User) {
+	if err := requireAdmin(user); err != nil {
+		respondWithError(w, http.StatusForbidden, err.Error())
+		return
+	}
+
+	users, err := api.state.database.GetUsers(r.Context())
+	if err != nil {
+		respondWithInternalError(w, "failed to get users", err)
+		return
+	}
+
+	response := make([]apiUser, 0, len(users))
+	for _, user := range users {
+		response = append(response, apiUserFromUser(user))
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+func (api *apiServer) handleFeeds(w http.ResponseWriter, r *http.Request, user database.User) {
+	feeds, err := api.state.database.GetFeeds(r.Context())
+	if err != nil {
+		respondWithInternalError(w, "failed to get feeds", err)
+		return
+	}
+
+	response := make([]apiFeed, 0, len(feeds))
+	for _, feed := range feeds {
+		response = append(response, apiFeed{
+			ID:       feed.FeedID,
+			Name:     feed.FeedName,
+			Url:      feed.FeedUrl,
+			UserName: feed.UserName,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// handleCreateFeed adds a feed and follows it, like "gator addfeed".
+func (api *apiServer) handleCreateFeed(w http.ResponseWriter, r *http.Request, user database.User) {
+	var body struct {
+		Name string `json:"name"`
+		Url  string `json:"url"`
+	}
+	if !decodeJSONBody(w, r, &body) {
+		return
+	}
+	if body.Name == "" || body.Url == "" {
+		respondWithError(w, http.StatusBadRequest, "name and url are required")
+		return
+	}
+
+	tx, err := api.state.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithInternalError(w, "failed to start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+
+	queries := api.state.database.WithTx(tx)
+
+	feed, err := queries.CreateFeed(r.Context(), database.CreateFeedParams{
+		ID:     uuid.New(),
+		UserID: user.ID,
+		Name:   body.Name,
+		Url:    body.Url,
+	})
+	if err != nil {
+		var pqErr *pq.Error
+
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			respondWithError(w, http.StatusConflict, ErrFeedExists.Error())
+			return
+		}
+
+		respondWithInternalError(w, "failed to create feed", err)
+		return
+	}
+
+	_, err = queries.CreateFeedFollow(r.Context(), database.CreateFeedFollowParams{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		FeedID:    feed.ID,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		respondWithInternalError(w, "failed to follow feed", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithInternalError(w, "failed to commit transaction", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, apiFeed{
+		ID:       feed.ID,
+		Name:     feed.Name,
+		Url:      feed.Url,
+		UserName: user.Name,
+	})
+}
+
+func (api *apiServer) handleFollows(w http.ResponseWriter, r *http.Request, user database.User) {
+	feedFollows, err := api.state.database.GetFeedFollowsForUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithInternalError(w, "failed to get feed follows", err)
+		return
+	}
+
+	response := make([]apiFollow, 0, len(feedFollows))
+	for _, feedFollow := range feedFollows {
+		response = append(response, apiFollow{
+			FeedID:      feedFollow.FeedID,
+			FeedName:    feedFollow.FeedName,
+			FeedUrl:     feedFollow.FeedUrl,
+			SiteUrl:     feedFollow.FeedSiteUrl,
+			UnreadCount: feedFollow.UnreadCount,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+func (api *apiServer) handleCreateFollow(w http.ResponseWriter, r *http.Request, user database.User) {
+	var body struct {
+		FeedUrl string `json:"feed_url"`
+	}
+	if !decodeJSONBody(w, r, &body) {
+		return
+	}
+
+	feed, err := api.state.database.FindFeedByUrl(r.Context(), body.FeedUrl)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, ErrFeedNotFound.Error())
+			return
+		}
+
+		respondWithInternalError(w, "failed to find feed", err)
+		return
+	}
+
+	_, err = api.state.database.CreateFeedFollow(r.Context(), database.CreateFeedFollowParams{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		FeedID:    feed.ID,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		var pqErr *pq.Error
+
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			respondWithError(w, http.StatusConflict, ErrFeedFollowExists.Error())
+			return
+		}
+
+		respondWithInternalError(w, "failed to follow feed", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, apiFollow{
+		FeedID:   feed.ID,
+		FeedName: feed.Name,
+		FeedUrl:  feed.Url,
+		SiteUrl:  feed.SiteUrl,
+	})
+}
+
+func (api *apiServer) handleDeleteFollow(w http.ResponseWriter, r *http.Request, user database.User) {
+	feedID, err := uuid.Parse(r.PathValue("feedID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid feed ID")
+		return
+	}
+
+	err = api.state.database.DeleteFeedFollow(r.Context(), database.DeleteFeedFollowParams{
+		UserID: user.ID,
+		FeedID: feedID,
+	})
+	if err != nil {
+		respondWithInternalError(w, "failed to unfollow feed", err)
+		return
+	}
+
+	err = api.state.database.RemoveFeedFromUserFolders(r.Context(), database.RemoveFeedFromUserFoldersParams{
+		UserID: user.ID,
+		FeedID: feedID,
+	})
+	if err != nil {
+		respondWithInternalError(w, "failed to remove the feed from folders", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parsePostsQuery maps the query parameters of GET /api/posts to the options
+// of the browse command, so both share buildPostsQuery.
+func parsePostsQuery(values url.Values) (browseOptions, error) {
+	opts := browseOptions{
+		limit:  defaultAPIPostLimit,
+		sortBy: sortByPublished,
+		cursor: values.Get("cursor"),
+		feed:   values.Get("feed"),
+		author: values.Get("author"),
+		since:  values.Get("since"),
+		before: values.Get("before"),
+		saved:  values.Get("saved"),
+		query:  values.Get("q"),
+		folder: values.Get("folder"),
+		tag:    values.Get("tag"),
+	}
+
+	if value := values.Get("sort"); value != "" {
+		opts.sortBy = value
+	}
+	if opts.sortBy != sortByPublished && opts.sortBy != sortByFetched {
+		return opts, fmt.Errorf("invalid sort %q, valid values are %q and %q", opts.sortBy, sortByPublished, sortByFetched)
+	}
+
+	intParams := []struct {
+		name  string
+		value *int
+	}{
+		{"limit", &opts.limit},
+		{"offset", &opts.offset},
+	}
+	for _, param := range intParams {
+		if value := values.Get(param.name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return opts, fmt.Errorf("invalid %v %q", param.name, value)
+			}
+			*param.value = n
+		}
+	}
+	if opts.limit == 0 || opts.limit > maxAPIPostLimit {
+		return opts, fmt.Errorf("the limit must be between 1 and %v", maxAPIPostLimit)
+	}
+	if opts.cursor != "" && opts.offset > 0 {
+		return opts, fmt.Errorf("cursor cannot be combined with offset")
+	}
+
+	boolParams := []struct {
+		name  string
+		value *bool
+	}{
+		{"unread", &opts.unread},
+		{"show_filtered", &opts.showFiltered},
+	}
+	for _, param := range boolParams {
+		if value := values.Get(param.name); value != "" {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return opts, fmt.Errorf("invalid %v %q", param.name, value)
+			}
+			*param.value = b
+		}
+	}
+
+	if opts.saved != "" && opts.query != "" {
+		return opts, fmt.Errorf("saved cannot be combined with q")
+	}
+
+	return opts, nil
+}
+
+func apiPostFromUserPost(post database.GetPostsForUserRow) apiPost {
+	var publishedAt *time.Time
+	if post.PublishedAt.Valid {
+		publishedAt = &post.PublishedAt.Time
+	}
+
+	return apiPost{
+		ID:          post.ID,
+		FeedID:      post.FeedID,
+		FeedName:    post.FeedName,
+		Title:       post.Title,
+		Url:         post.Url,
+		Author:      post.Author,
+		Description: post.Description,
+		Content:     post.Content,
+		Categories:  post.Categories,
+		Tags:        post.Tags,
+		PublishedAt: publishedAt,
+		CreatedAt:   post.CreatedAt,
+		IsRead:      post.IsRead,
+		IsStarred:   post.IsStarred,
+		IsFiltered:  post.IsFiltered,
+	}
+}
+
+func (api *apiServer) handlePosts(w http.ResponseWriter, r *http.Request, user database.User) {
+	opts, err := parsePostsQuery(r.URL.Query())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if opts.saved != "" {
+		savedSearch, err := findSavedSearch(api.state, user.ID, opts.saved)
+		if err != nil {
+			respondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		opts.query = savedSearch.Query
+	}
+
+	params, err := buildPostsQuery(user.ID, opts, time.Now())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userPosts, err := getPostsForUser(r.Context(), api.state.database, opts.sortBy, params)
+	if err != nil {
+		respondWithInternalError(w, "failed to get posts", err)
+		return
+	}
+
+	response := apiPostPage{
+		Posts: make([]apiPost, 0, len(userPosts)),
+	}
+	for _, post := range userPosts {
+		response.Posts = append(response.Posts, apiPostFromUserPost(post))
+	}
+
+	if len(userPosts) == opts.limit {
+		lastPost := userPosts[len(userPosts)-1]
+		response.NextCursor = encodeCursor(opts.sortBy, lastPost.SortKey, lastPost.ID)
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// findAPIPost looks up the post of the {postID} path parameter among the posts
+// of the feeds the user follows. It responds with an error and returns false
+// if the post cannot be used.
+func (api *apiServer) findAPIPost(w http.ResponseWriter, r *http.Request, user database.User) (database.FindPostForUserRow, bool) {
+	post, err := findPostForUser(api.state, r.PathValue("postID"), user.ID)
+	if err != nil {
+		if errors.Is(err, ErrPostNotFound) {
+			respondWithError(w, http.StatusNotFound, err.Error())
+			return post, false
+		}
+
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return post, false
+	}
+
+	return post, true
+}
+
+func (api *apiServer) handleMarkRead(w http.ResponseWriter, r *http.Request, user database.User) {
+	post, ok := api.findAPIPost(w, r, user)
+	if !ok {
+		return
+	}
+
+	err := api.state.database.MarkPostRead(r.Context(), database.MarkPostReadParams{
+		UserID: user.ID,
+		PostID: post.ID,
+		ReadAt: time.Now(),
+	})
+	if err != nil {
+		respondWithInternalError(w, "failed to mark post as read", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (api *apiServer) handleMarkUnread(w http.ResponseWriter, r *http.Request, user database.User) {
+	post, ok := api.findAPIPost(w, r, user)
+	if !ok {
+		return
+	}
+
+	_, err := api.state.database.MarkPostUnread(r.Context(), database.MarkPostUnreadParams{
+		UserID: user.ID,
+		PostID: post.ID,
+	})
+	if err != nil {
+		respondWithInternalError(w, "failed to mark post as unread", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (api *apiServer) handleStar(w http.ResponseWriter, r *http.Request, user database.User) {
+	post, ok := api.findAPIPost(w, r, user)
+	if !ok {
+		return
+	}
+
+	// The note is optional, starring works without a body. Without a note
+	// the old one is kept, an empty note removes it.
+	var body struct {
+		Note *string `json:"note"`
+	}
+	if r.ContentLength != 0 {
+		if !decodeJSONBody(w, r, &body) {
+			return
+		}
+	}
+
+	note := sql.NullString{}
+	if body.Note != nil {
+		note = sql.NullString{String: strings.TrimSpace(*body.Note), Valid: true}
+	}
+
+	_, err := api.state.database.StarPost(r.Context(), database.StarPostParams{
+		UserID:    user.ID,
+		PostID:    post.ID,
+		Note:      note,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	})
+	if err != nil {
+		respondWithInternalError(w, "failed to star post", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleUnstar only needs the post ID, bookmarks outlive follows.
+func (api *apiServer) handleUnstar(w http.ResponseWriter, r *http.Request, user database.User) {
+	postID, err := uuid.Parse(r.PathValue("postID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid post ID")
+		return
+	}
+
+	removedCount, err := api.state.database.UnstarPost(r.Context(), database.UnstarPostParams{
+		UserID: user.ID,
+		PostID: postID,
+	})
+	if err != nil {
+		respondWithInternalError(w, "failed to unstar post", err)
+		return
+	}
+
+	if removedCount == 0 {
+		respondWithError(w, http.StatusNotFound, "post is not starred")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
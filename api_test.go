@@ -0,0 +1,383 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/auth"
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/database"
+	"github.com/google/uuid"
+)
+
+func createTestAPIKey(t *testing.T, s *state, userID uuid.UUID, scope string, expiresAt sql.NullTime) string {
+	t.Helper()
+
+	key, err := auth.NewAPIKey()
+	if err != nil {
+		t.Fatalf("failed to generate API key: %v", err)
+	}
+
+	_, err = s.database.CreateAPIKey(context.Background(), database.CreateAPIKeyParams{
+		ID:        uuid.New(),
+		UserID:    userID,
+		KeyHash:   auth.HashAPIKey(key),
+		CreatedAt: time.Now(),
+		Scope:     scope,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		t.Fatalf("failed to create API key: %v", err)
+	}
+
+	return key
+}
+
+func apiRequest(t *testing.T, server http.Handler, method string, path string, authorization string, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func decodeResponse[T any](t *testing.T, rec *httptest.ResponseRecorder) T {
+	t.Helper()
+
+	var payload T
+	if err := json.Unmarshal(rec.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("failed to decode response %q: %v", rec.Body.String(), err)
+	}
+
+	return payload
+}
+
+// getAPIPost returns the post from GET /api/posts, which is where clients see
+// the read and starred flags.
+func getAPIPost(t *testing.T, server http.Handler, key string, postID uuid.UUID) apiPost {
+	t.Helper()
+
+	rec := apiRequest(t, server, http.MethodGet, "/api/posts?limit=100", "Bearer "+key, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/posts = %v %v, want 200", rec.Code, rec.Body.String())
+	}
+
+	page := decodeResponse[apiPostPage](t, rec)
+	for _, post := range page.Posts {
+		if post.ID == postID {
+			return post
+		}
+	}
+
+	t.Fatalf("post %v is missing from GET /api/posts", postID)
+	return apiPost{}
+}
+
+func TestAPIAuthentication(t *testing.T) {
+	s := newTestState(t)
+	fixture := createTestFixture(t, s, "alice", 1)
+	server := newServer(s)
+
+	validKey := createTestAPIKey(t, s, fixture.user.ID, apiKeyScopeReadWrite, sql.NullTime{})
+	expiredKey := createTestAPIKey(t, s, fixture.user.ID, apiKeyScopeReadWrite, sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true})
+	futureKey := createTestAPIKey(t, s, fixture.user.ID, apiKeyScopeRead, sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true})
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantCode      int
+		wantError     string
+	}{
+		{"missing header", "", http.StatusUnauthorized, "missing API key"},
+		{"empty key", "Bearer ", http.StatusUnauthorized, "missing API key"},
+		{"other scheme", "Basic " + validKey, http.StatusUnauthorized, "missing API key"},
+		{"unknown key", "Bearer gator_unknown", http.StatusUnauthorized, "invalid or expired API key"},
+		{"key with extra characters", "Bearer " + validKey + "x", http.StatusUnauthorized, "invalid or expired API key"},
+		{"expired key", "Bearer " + expiredKey, http.StatusUnauthorized, "invalid or expired API key"},
+		{"valid key", "Bearer " + validKey, http.StatusOK, ""},
+		{"key expiring later", "Bearer " + futureKey, http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := apiRequest(t, server, http.MethodGet, "/api/me", tt.authorization, "")
+			if rec.Code != tt.wantCode {
+				t.Fatalf("GET /api/me = %v %v, want %v", rec.Code, rec.Body.String(), tt.wantCode)
+			}
+
+			if tt.wantCode == http.StatusUnauthorized {
+				if got := rec.Header().Get("WWW-Authenticate"); got != "Bearer" {
+					t.Errorf("WWW-Authenticate = %q, want \"Bearer\"", got)
+				}
+				if got := decodeResponse[apiError](t, rec).Error; got != tt.wantError {
+					t.Errorf("error = %q, want %q", got, tt.wantError)
+				}
+				return
+			}
+
+			me := decodeResponse[apiUser](t, rec)
+			if me.ID != fixture.user.ID || me.Name != "alice" {
+				t.Errorf("GET /api/me = %+v, want alice", me)
+			}
+		})
+	}
+}
+
+func TestAPIReadOnlyKey(t *testing.T) {
+	s := newTestState(t)
+	fixture := createTestFixture(t, s, "alice", 1)
+	server := newServer(s)
+
+	readKey := createTestAPIKey(t, s, fixture.user.ID, apiKeyScopeRead, sql.NullTime{})
+	postID := fixture.posts[0].ID.String()
+
+	rec := apiRequest(t, server, http.MethodGet, "/api/posts", "Bearer "+readKey, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/posts = %v %v, want 200", rec.Code, rec.Body.String())
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{http.MethodPut, "/api/posts/" + postID + "/read", ""},
+		{http.MethodDelete, "/api/posts/" + postID + "/read", ""},
+		{http.MethodPut, "/api/posts/" + postID + "/star", `{"note": "later"}`},
+		{http.MethodDelete, "/api/posts/" + postID + "/star", ""},
+		{http.MethodPost, "/api/feeds", `{"name": "Other", "url": "https://other.example.com/feed.xml"}`},
+		{http.MethodDelete, "/api/follows/" + fixture.feed.ID.String(), ""},
+	}
+
+	for _, tt := range tests {
+		rec := apiRequest(t, server, tt.method, tt.path, "Bearer "+readKey, tt.body)
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("%v %v = %v %v, want 403", tt.method, tt.path, rec.Code, rec.Body.String())
+			continue
+		}
+		if got := decodeResponse[apiError](t, rec).Error; got != ErrAPIKeyReadOnly.Error() {
+			t.Errorf("%v %v error = %q, want %q", tt.method, tt.path, got, ErrAPIKeyReadOnly)
+		}
+	}
+
+	// None of the rejected requests changed anything
+	post := getAPIPost(t, server, readKey, fixture.posts[0].ID)
+	if post.IsRead || post.IsStarred {
+		t.Errorf("post after rejected writes: is_read = %v, is_starred = %v, want both false", post.IsRead, post.IsStarred)
+	}
+}
+
+func TestAPIPostsPagination(t *testing.T) {
+	s := newTestState(t)
+	fixture := createTestFixture(t, s, "alice", 5)
+	server := newServer(s)
+	key := createTestAPIKey(t, s, fixture.user.ID, apiKeyScopeRead, sql.NullTime{})
+
+	var gotIDs []uuid.UUID
+	var pageSizes []int
+	var firstCursor string
+	path := "/api/posts?limit=2"
+	for range 10 {
+		rec := apiRequest(t, server, http.MethodGet, path, "Bearer "+key, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %v = %v %v, want 200", path, rec.Code, rec.Body.String())
+		}
+
+		page := decodeResponse[apiPostPage](t, rec)
+		pageSizes = append(pageSizes, len(page.Posts))
+		for _, post := range page.Posts {
+			gotIDs = append(gotIDs, post.ID)
+		}
+
+		if page.NextCursor == "" {
+			break
+		}
+		if firstCursor == "" {
+			firstCursor = page.NextCursor
+		}
+		path = "/api/posts?limit=2&cursor=" + page.NextCursor
+	}
+
+	// The fixture posts are newest first, the order of the published sort
+	var wantIDs []uuid.UUID
+	for _, post := range fixture.posts {
+		wantIDs = append(wantIDs, post.ID)
+	}
+
+	if !slices.Equal(pageSizes, []int{2, 2, 1}) {
+		t.Errorf("page sizes = %v, want [2 2 1]", pageSizes)
+	}
+	if !slices.Equal(gotIDs, wantIDs) {
+		t.Errorf("paginated posts = %v, want %v", gotIDs, wantIDs)
+	}
+
+	badRequests := []struct {
+		path string
+		want string
+	}{
+		{"/api/posts?limit=0", "the limit must be between 1 and 100"},
+		{"/api/posts?limit=101", "the limit must be between 1 and 100"},
+		{"/api/posts?limit=two", `invalid limit "two"`},
+		{"/api/posts?sort=title", `invalid sort "title"`},
+		{"/api/posts?cursor=" + firstCursor + "&offset=2", "cursor cannot be combined with offset"},
+		{"/api/posts?sort=fetched&cursor=" + firstCursor, `the cursor belongs to the "published" sort`},
+		{"/api/posts?cursor=garbage", "cursor"},
+	}
+
+	for _, tt := range badRequests {
+		rec := apiRequest(t, server, http.MethodGet, tt.path, "Bearer "+key, "")
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("GET %v = %v %v, want 400", tt.path, rec.Code, rec.Body.String())
+			continue
+		}
+		if got := decodeResponse[apiError](t, rec).Error; !strings.Contains(got, tt.want) {
+			t.Errorf("GET %v error = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestAPIReadAndStarRoundTrip(t *testing.T) {
+	s := newTestState(t)
+	fixture := createTestFixture(t, s, "alice", 2)
+	other := createTestFixture(t, s, "bob", 1)
+	server := newServer(s)
+
+	key := createTestAPIKey(t, s, fixture.user.ID, apiKeyScopeReadWrite, sql.NullTime{})
+	postID := fixture.posts[0].ID
+	postPath := "/api/posts/" + postID.String()
+
+	expectCode := func(method string, path string, body string, want int) {
+		t.Helper()
+
+		rec := apiRequest(t, server, method, path, "Bearer "+key, body)
+		if rec.Code != want {
+			t.Fatalf("%v %v = %v %v, want %v", method, path, rec.Code, rec.Body.String(), want)
+		}
+	}
+
+	expectNote := func(want string) {
+		t.Helper()
+
+		bookmarks, err := s.database.GetBookmarksForUser(context.Background(), database.GetBookmarksForUserParams{
+			UserID: fixture.user.ID,
+			Limit:  10,
+		})
+		if err != nil {
+			t.Fatalf("failed to get bookmarks: %v", err)
+		}
+		if len(bookmarks) != 1 || bookmarks[0].ID != postID {
+			t.Fatalf("bookmarks = %+v, want only post %v", bookmarks, postID)
+		}
+		if bookmarks[0].Note != want {
+			t.Errorf("note = %q, want %q", bookmarks[0].Note, want)
+		}
+	}
+
+	// Read and unread
+	expectCode(http.MethodPut, postPath+"/read", "", http.StatusNoContent)
+	if post := getAPIPost(t, server, key, postID); !post.IsRead {
+		t.Errorf("post is not read after PUT /read")
+	}
+	if post := getAPIPost(t, server, key, fixture.posts[1].ID); post.IsRead {
+		t.Errorf("another post is read after PUT /read")
+	}
+
+	// Marking twice is not an error
+	expectCode(http.MethodPut, postPath+"/read", "", http.StatusNoContent)
+
+	expectCode(http.MethodDelete, postPath+"/read", "", http.StatusNoContent)
+	if post := getAPIPost(t, server, key, postID); post.IsRead {
+		t.Errorf("post is still read after DELETE /read")
+	}
+
+	// Star, keep the note when none is sent, clear it with an empty one
+	expectCode(http.MethodPut, postPath+"/star", `{"note": " read later "}`, http.StatusNoContent)
+	if post := getAPIPost(t, server, key, postID); !post.IsStarred {
+		t.Errorf("post is not starred after PUT /star")
+	}
+	expectNote("read later")
+
+	expectCode(http.MethodPut, postPath+"/star", "", http.StatusNoContent)
+	expectNote("read later")
+
+	expectCode(http.MethodPut, postPath+"/star", `{"note": ""}`, http.StatusNoContent)
+	expectNote("")
+
+	expectCode(http.MethodPut, postPath+"/star", `{"tags": []}`, http.StatusBadRequest)
+
+	expectCode(http.MethodDelete, postPath+"/star", "", http.StatusNoContent)
+	if post := getAPIPost(t, server, key, postID); post.IsStarred {
+		t.Errorf("post is still starred after DELETE /star")
+	}
+	expectCode(http.MethodDelete, postPath+"/star", "", http.StatusNotFound)
+
+	// Posts of feeds the user does not follow and invalid IDs
+	otherPath := "/api/posts/" + other.posts[0].ID.String()
+	expectCode(http.MethodPut, otherPath+"/read", "", http.StatusNotFound)
+	expectCode(http.MethodPut, otherPath+"/star", "", http.StatusNotFound)
+	expectCode(http.MethodPut, "/api/posts/"+uuid.NewString()+"/read", "", http.StatusNotFound)
+	expectCode(http.MethodPut, "/api/posts/not-a-uuid/read", "", http.StatusBadRequest)
+	expectCode(http.MethodDelete, "/api/posts/not-a-uuid/star", "", http.StatusBadRequest)
+}
+
+func TestDecodeJSONBody(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		wantOK   bool
+		wantCode int
+	}{
+		{"valid", `{"note": "later"}`, true, 0},
+		{"unknown field", `{"tags": []}`, false, http.StatusBadRequest},
+		{"malformed", `{"note": `, false, http.StatusBadRequest},
+		{"too large", `{"note": "` + strings.Repeat("a", maxAPIBodySize) + `"}`, false, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/api/posts/id/star", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+
+			var body struct {
+				Note *string `json:"note"`
+			}
+			if ok := decodeJSONBody(rec, req, &body); ok != tt.wantOK {
+				t.Fatalf("decodeJSONBody = %v, want %v", ok, tt.wantOK)
+			}
+			if tt.wantOK {
+				if body.Note == nil || *body.Note != "later" {
+					t.Errorf("note = %v, want \"later\"", body.Note)
+				}
+				return
+			}
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("status = %v, want %v", rec.Code, tt.wantCode)
+			}
+			if got := decodeResponse[apiError](t, rec).Error; got == "" {
+				t.Errorf("the response has no error message")
+			}
+		})
+	}
+}
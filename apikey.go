@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/auth"
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	apiKeyScopeRead      = "read"
+	apiKeyScopeReadWrite = "read-write"
+)
+
+var ErrAPIKeyNotFound = errors.New("API key not found")
+var ErrAPIKeyReadOnly = errors.New("the API key is read-only")
+
+const apiKeyUsage = `Usage:
+  gator apikey create [label] [--scope read|read-write] [--expires 90d|2006-01-02]
+  gator apikey list
+  gator apikey revoke <id>`
+
+func handleAPIKey(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return fmt.Errorf("the apikey command requires a sub command.\n%v", apiKeyUsage)
+	}
+
+	subCmd := command{
+		name: cmd.name + " " + cmd.args[0],
+		args: cmd.args[1:],
+	}
+
+	switch cmd.args[0] {
+	case "create":
+		return handleAPIKeyCreate(s, subCmd, user)
+	case "list":
+		return handleAPIKeyList(s, subCmd, user)
+	case "revoke":
+		return handleAPIKeyRevoke(s, subCmd, user)
+	}
+
+	return fmt.Errorf("unknown apikey sub command: %v\n%v", cmd.args[0], apiKeyUsage)
+}
+
+// parseExpiry parses either a duration from now (e.g. "24h", "90d") or an
+// absolute date ("2006-01-02" or RFC3339) in the future.
+func parseExpiry(value string, now time.Time) (time.Time, error) {
+	var expiresAt time.Time
+
+	// parseTimeFilter would go back in time for durations
+	if duration, err := parseDuration(value); err == nil {
+		expiresAt = now.Add(duration)
+	} else {
+		expiresAt, err = parseTimeFilter(value, now)
+		if err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	if !expiresAt.After(now) {
+		return time.Time{}, fmt.Errorf("the expiry %q is not in the future", value)
+	}
+
+	return expiresAt, nil
+}
+
+// handleAPIKeyCreate generates a new API key for the user. Only the hash of
+// the key is stored, so the key is printed once and cannot be shown again.
+func handleAPIKeyCreate(s *state, cmd command, user database.User) error {
+	flags := newFlagSet(cmd.name)
+	scope := flags.String("scope", apiKeyScopeReadWrite, "\"read\" for read-only access or \"read-write\"")
+	expires := flags.String("expires", "", "expire the key after a duration (90d) or on a date (2006-01-02)")
+
+	args, err := parseFlags(flags, cmd.args)
+	if err != nil {
+		return fmt.Errorf("%v\n%v", err, apiKeyUsage)
+	}
+
+	if *scope != apiKeyScopeRead && *scope != apiKeyScopeReadWrite {
+		return fmt.Errorf("invalid scope %q, valid values are %q and %q", *scope, apiKeyScopeRead, apiKeyScopeReadWrite)
+	}
+
+	expiresAt := sql.NullTime{}
+	if *expires != "" {
+		expiry, err := parseExpiry(*expires, time.Now())
+		if err != nil {
+			return fmt.Errorf("invalid --expires value: %v", err)
+		}
+		expiresAt = sql.NullTime{Time: expiry, Valid: true}
+	}
+
+	key, err := auth.NewAPIKey()
+	if err != nil {
+		return fmt.Errorf("failed to generate API key: %v", err)
+	}
+
+	apiKey, err := s.database.CreateAPIKey(context.Background(), database.CreateAPIKeyParams{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		KeyHash:   auth.HashAPIKey(key),
+		CreatedAt: time.Now(),
+		Label:     strings.Join(args, " "),
+		Scope:     *scope,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create API key: %v", err)
+	}
+
+	fmt.Printf("API key created for %v\n", user.Name)
+	printAPIKey(apiKey, time.Now())
+	fmt.Printf("- Key: %v\n", key)
+	fmt.Println("Store the key now, it cannot be shown again")
+
+	return nil
+}
+
+func printAPIKey(apiKey database.ApiKey, now time.Time) {
+	fmt.Printf("- Id: %v\n", apiKey.ID)
+	if apiKey.Label != "" {
+		fmt.Printf("- Label: %v\n", apiKey.Label)
+	}
+	fmt.Printf("- Scope: %v\n", apiKey.Scope)
+	fmt.Printf("- Created: %v\n", apiKey.CreatedAt.Format("02 January 2006 15:04"))
+
+	lastUsed := "never"
+	if apiKey.LastUsedAt.Valid {
+		lastUsed = fmt.Sprintf("%v ago", formatAge(now.Sub(apiKey.LastUsedAt.Time)))
+	}
+	fmt.Printf("- Last used: %v\n", lastUsed)
+
+	if apiKey.ExpiresAt.Valid {
+		status := ""
+		if !apiKey.ExpiresAt.Time.After(now) {
+			status = " (expired)"
+		}
+		fmt.Printf("- Expires: %v%v\n", apiKey.ExpiresAt.Time.Format("02 January 2006 15:04"), status)
+	}
+}
+
+func handleAPIKeyList(s *state, cmd command, user database.User) error {
+	apiKeys, err := s.database.GetAPIKeysForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get API keys: %v", err)
+	}
+
+	if len(apiKeys) == 0 {
+		fmt.Println("No API keys found. Use: gator apikey create [label]")
+		return nil
+	}
+
+	now := time.Now()
+	fmt.Printf("API keys of %v\n", user.Name)
+	fmt.Printf("--------------------------------\n")
+	for _, apiKey := range apiKeys {
+		printAPIKey(apiKey, now)
+		fmt.Printf("--------------------------------\n")
+	}
+
+	return nil
+}
+
+func handleAPIKeyRevoke(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return fmt.Errorf("the apikey revoke command requires an API key ID.\n%v", apiKeyUsage)
+	}
+
+	apiKeyID, err := uuid.Parse(cmd.args[0])
+	if err != nil {
+		return fmt.Errorf("invalid API key ID %q: %v", cmd.args[0], err)
+	}
+
+	removedCount, err := s.database.DeleteAPIKey(context.Background(), database.DeleteAPIKeyParams{
+		ID:     apiKeyID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to revoke API key: %v", err)
+	}
+
+	if removedCount == 0 {
+		return fmt.Errorf("%w: %v", ErrAPIKeyNotFound, apiKeyID)
+	}
+
+	fmt.Printf("API key revoked: %v\n", apiKeyID)
+
+	return nil
+}
@@ -0,0 +1,671 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/database"
+	"github.com/google/uuid"
+)
+
+// A backup is a newline delimited JSON archive. The first line is a header
+// describing the archive, every other line is a single database row:
+//
+//	{"type":"header","data":{"format":"gator-backup","version":1,"schema_version":14,...}}
+//	{"type":"user","data":{"id":"...","name":"alice",...}}
+//
+// Rows are written in dependency order (users before feeds, feeds before
+// posts, ...) so the archive can be restored in a single pass.
+const (
+	backupFormat  = "gator-backup"
+	backupVersion = 1
+)
+
+const (
+	backupTypeHeader      = "header"
+	backupTypeUser        = "user"
+	backupTypeFeed        = "feed"
+	backupTypeFeedFollow  = "feed_follow"
+	backupTypePost        = "post"
+	backupTypePostRead    = "post_read"
+	backupTypeBookmark    = "bookmark"
+	backupTypeSavedSearch = "saved_search"
+	backupTypePostFilter  = "post_filter"
+	backupTypeRule        = "rule"
+	backupTypePostTag     = "post_tag"
+	backupTypeFolder      = "folder"
+	backupTypeFolderFeed  = "folder_feed"
+	backupTypeWebhook     = "webhook"
+
+	backupTypeDigestSubscription = "digest_subscription"
+	backupTypeDigest             = "digest"
+	backupTypeDigestPost         = "digest_post"
+)
+
+// backupTypes lists the row types in the order they are written and
+// reported.
+var backupTypes = []string{
+	backupTypeUser,
+	backupTypeFeed,
+	backupTypeFeedFollow,
+	backupTypePost,
+	backupTypePostRead,
+	backupTypeBookmark,
+	backupTypeSavedSearch,
+	backupTypePostFilter,
+	backupTypeRule,
+	backupTypePostTag,
+	backupTypeFolder,
+	backupTypeFolderFeed,
+	backupTypeWebhook,
+	backupTypeDigestSubscription,
+	backupTypeDigest,
+	backupTypeDigestPost,
+}
+
+// backupExcluded names the tables which are left out on purpose, they hold
+// credentials which should not outlive the database they were issued by.
+// Users log in again and create new keys and tokens after a restore.
+var backupExcluded = []string{"login sessions", "API keys", "river tokens", "webhook deliveries"}
+
+var ErrInvalidBackup = errors.New("invalid backup")
+
+func printBackupExcluded(out io.Writer) {
+	fmt.Fprintf(out, "Not backed up: %v\n", strings.Join(backupExcluded, ", "))
+}
+
+type backupRecord struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+type backupHeader struct {
+	Format        string    `json:"format"`
+	Version       int       `json:"version"`
+	SchemaVersion int64     `json:"schema_version"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type backupUser struct {
+	ID           uuid.UUID `json:"id"`
+	Name         string    `json:"name"`
+	PasswordHash string    `json:"password_hash"`
+	Role         string    `json:"role"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type backupFeed struct {
+	ID            uuid.UUID  `json:"id"`
+	UserID        uuid.UUID  `json:"user_id"`
+	Url           string     `json:"url"`
+	Name          string     `json:"name"`
+	SiteUrl       string     `json:"site_url"`
+	CreatedAt     time.Time  `json:"created_at"`
+	LastFetchedAt *time.Time `json:"last_fetched_at"`
+}
+
+type backupFeedFollow struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    uuid.UUID `json:"user_id"`
+	FeedID    uuid.UUID `json:"feed_id"`
+	Notify    bool      `json:"notify"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type backupPost struct {
+	ID          uuid.UUID  `json:"id"`
+	FeedID      uuid.UUID  `json:"feed_id"`
+	Title       string     `json:"title"`
+	Url         string     `json:"url"`
+	Description string     `json:"description"`
+	Author      string     `json:"author"`
+	Content     string     `json:"content"`
+	Categories  []string   `json:"categories"`
+	PublishedAt *time.Time `json:"published_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+type backupPostRead struct {
+	UserID uuid.UUID `json:"user_id"`
+	PostID uuid.UUID `json:"post_id"`
+	ReadAt time.Time `json:"read_at"`
+}
+
+type backupBookmark struct {
+	UserID          uuid.UUID  `json:"user_id"`
+	PostID          uuid.UUID  `json:"post_id"`
+	Note            string     `json:"note"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	PostTitle       string     `json:"post_title"`
+	PostUrl         string     `json:"post_url"`
+	PostDescription string     `json:"post_description"`
+	PostAuthor      string     `json:"post_author"`
+	PostPublishedAt *time.Time `json:"post_published_at"`
+	FeedName        string     `json:"feed_name"`
+}
+
+type backupSavedSearch struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Name      string    `json:"name"`
+	Query     string    `json:"query"`
+	Notify    bool      `json:"notify"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type backupPostFilter struct {
+	ID        uuid.UUID  `json:"id"`
+	UserID    uuid.UUID  `json:"user_id"`
+	FeedID    *uuid.UUID `json:"feed_id"`
+	Kind      string     `json:"kind"`
+	Pattern   string     `json:"pattern"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type backupRule struct {
+	ID         uuid.UUID `json:"id"`
+	UserID     uuid.UUID `json:"user_id"`
+	Name       string    `json:"name"`
+	Expression string    `json:"expression"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type backupPostTag struct {
+	UserID    uuid.UUID `json:"user_id"`
+	PostID    uuid.UUID `json:"post_id"`
+	Tag       string    `json:"tag"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type backupFolder struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type backupFolderFeed struct {
+	FolderID  uuid.UUID `json:"folder_id"`
+	FeedID    uuid.UUID `json:"feed_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// backupWebhook includes the signing secret, so receivers keep verifying the
+// deliveries after a restore.
+type backupWebhook struct {
+	ID        uuid.UUID  `json:"id"`
+	UserID    uuid.UUID  `json:"user_id"`
+	Name      string     `json:"name"`
+	Url       string     `json:"url"`
+	Format    string     `json:"format"`
+	Secret    string     `json:"secret"`
+	FeedID    *uuid.UUID `json:"feed_id"`
+	Tag       *string    `json:"tag"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type backupDigestSubscription struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Email     string    `json:"email"`
+	Frequency string    `json:"frequency"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// The digest history is backed up with the subscriptions, without it every
+// unread post since the subscription would be sent again.
+type backupDigest struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Email     string    `json:"email"`
+	PostCount int32     `json:"post_count"`
+	SentAt    time.Time `json:"sent_at"`
+}
+
+type backupDigestPost struct {
+	DigestID uuid.UUID `json:"digest_id"`
+	PostID   uuid.UUID `json:"post_id"`
+}
+
+func nullTimeToPtr(value sql.NullTime) *time.Time {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Time
+}
+
+func ptrToNullTime(value *time.Time) sql.NullTime {
+	if value == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *value, Valid: true}
+}
+
+// schemaVersion returns the current goose migration version of the database.
+// goose records every migration applied or rolled back, the newest entry of a
+// version tells whether it is applied. The goose table is not part of the
+// schema known to sqlc, so it is queried directly.
+func schemaVersion(s *state) (int64, error) {
+	rows, err := s.db.QueryContext(context.Background(), "SELECT version_id, is_applied FROM goose_db_version ORDER BY id DESC")
+	if err != nil {
+		return 0, fmt.Errorf("failed to read the schema version: %v", err)
+	}
+	defer rows.Close()
+
+	rolledBack := map[int64]bool{}
+	for rows.Next() {
+		var version int64
+		var isApplied bool
+		if err := rows.Scan(&version, &isApplied); err != nil {
+			return 0, fmt.Errorf("failed to read the schema version: %v", err)
+		}
+
+		if rolledBack[version] {
+			continue
+		}
+		if isApplied {
+			return version, nil
+		}
+		rolledBack[version] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read the schema version: %v", err)
+	}
+
+	return 0, nil
+}
+
+type backupWriter struct {
+	encoder *json.Encoder
+	counts  map[string]int
+}
+
+func (w *backupWriter) write(recordType string, data any) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	if err := w.encoder.Encode(backupRecord{Type: recordType, Data: raw}); err != nil {
+		return err
+	}
+
+	w.counts[recordType]++
+	return nil
+}
+
+func handleBackup(s *state, cmd command) error {
+	version, err := schemaVersion(s)
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = os.Stdout
+	var file *os.File
+	if len(cmd.args) > 0 {
+		file, err = os.Create(cmd.args[0])
+		if err != nil {
+			return fmt.Errorf("failed to create backup file: %v", err)
+		}
+		// Only closes the file on errors, the close below reports write errors
+		defer file.Close()
+
+		out = file
+	}
+
+	// Read everything from a single snapshot so the archive is consistent
+	// even while the aggregator keeps adding posts.
+	tx, err := s.db.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	w := &backupWriter{
+		encoder: json.NewEncoder(out),
+		counts:  map[string]int{},
+	}
+
+	err = w.write(backupTypeHeader, backupHeader{
+		Format:        backupFormat,
+		Version:       backupVersion,
+		SchemaVersion: version,
+		CreatedAt:     time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write backup: %v", err)
+	}
+
+	if err := writeBackup(s.database.WithTx(tx), w); err != nil {
+		return fmt.Errorf("failed to write backup: %v", err)
+	}
+
+	if file != nil {
+		if err := file.Close(); err != nil {
+			return fmt.Errorf("failed to write backup file: %v", err)
+		}
+
+		fmt.Printf("Backup written to %v\n", cmd.args[0])
+		fmt.Printf("- Schema version: %v\n", version)
+		for _, recordType := range backupTypes {
+			fmt.Printf("- %v: %v\n", recordType, w.counts[recordType])
+		}
+		printBackupExcluded(os.Stdout)
+	} else {
+		// stdout is the archive, the note goes next to errors
+		printBackupExcluded(os.Stderr)
+	}
+
+	return nil
+}
+
+func writeBackup(queries *database.Queries, w *backupWriter) error {
+	ctx := context.Background()
+
+	users, err := queries.BackupUsers(ctx)
+	if err != nil {
+		return err
+	}
+	for _, user := range users {
+		err := w.write(backupTypeUser, backupUser{
+			ID:           user.ID,
+			Name:         user.Name,
+			PasswordHash: user.PasswordHash,
+			Role:         user.Role,
+			CreatedAt:    user.CreatedAt,
+			UpdatedAt:    user.UpdatedAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	feeds, err := queries.BackupFeeds(ctx)
+	if err != nil {
+		return err
+	}
+	for _, feed := range feeds {
+		err := w.write(backupTypeFeed, backupFeed{
+			ID:            feed.ID,
+			UserID:        feed.UserID,
+			Url:           feed.Url,
+			Name:          feed.Name,
+			SiteUrl:       feed.SiteUrl,
+			CreatedAt:     feed.CreatedAt,
+			LastFetchedAt: nullTimeToPtr(feed.LastFetchedAt),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	feedFollows, err := queries.BackupFeedFollows(ctx)
+	if err != nil {
+		return err
+	}
+	for _, feedFollow := range feedFollows {
+		err := w.write(backupTypeFeedFollow, backupFeedFollow{
+			ID:        feedFollow.ID,
+			UserID:    feedFollow.UserID,
+			FeedID:    feedFollow.FeedID,
+			Notify:    feedFollow.Notify,
+			CreatedAt: feedFollow.CreatedAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	posts, err := queries.BackupPosts(ctx)
+	if err != nil {
+		return err
+	}
+	for _, post := range posts {
+		err := w.write(backupTypePost, backupPost{
+			ID:          post.ID,
+			FeedID:      post.FeedID,
+			Title:       post.Title,
+			Url:         post.Url,
+			Description: post.Description,
+			Author:      post.Author,
+			Content:     post.Content,
+			Categories:  post.Categories,
+			PublishedAt: nullTimeToPtr(post.PublishedAt),
+			CreatedAt:   post.CreatedAt,
+			UpdatedAt:   post.UpdatedAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	postReads, err := queries.BackupPostReads(ctx)
+	if err != nil {
+		return err
+	}
+	for _, postRead := range postReads {
+		err := w.write(backupTypePostRead, backupPostRead{
+			UserID: postRead.UserID,
+			PostID: postRead.PostID,
+			ReadAt: postRead.ReadAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	bookmarks, err := queries.BackupBookmarks(ctx)
+	if err != nil {
+		return err
+	}
+	for _, bookmark := range bookmarks {
+		err := w.write(backupTypeBookmark, backupBookmark{
+			UserID:          bookmark.UserID,
+			PostID:          bookmark.PostID,
+			Note:            bookmark.Note,
+			CreatedAt:       bookmark.CreatedAt,
+			UpdatedAt:       bookmark.UpdatedAt,
+			PostTitle:       bookmark.PostTitle,
+			PostUrl:         bookmark.PostUrl,
+			PostDescription: bookmark.PostDescription,
+			PostAuthor:      bookmark.PostAuthor,
+			PostPublishedAt: nullTimeToPtr(bookmark.PostPublishedAt),
+			FeedName:        bookmark.FeedName,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	savedSearches, err := queries.BackupSavedSearches(ctx)
+	if err != nil {
+		return err
+	}
+	for _, savedSearch := range savedSearches {
+		err := w.write(backupTypeSavedSearch, backupSavedSearch{
+			ID:        savedSearch.ID,
+			UserID:    savedSearch.UserID,
+			Name:      savedSearch.Name,
+			Query:     savedSearch.Query,
+			Notify:    savedSearch.Notify,
+			CreatedAt: savedSearch.CreatedAt,
+			UpdatedAt: savedSearch.UpdatedAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	postFilters, err := queries.BackupPostFilters(ctx)
+	if err != nil {
+		return err
+	}
+	for _, postFilter := range postFilters {
+		record := backupPostFilter{
+			ID:        postFilter.ID,
+			UserID:    postFilter.UserID,
+			Kind:      postFilter.Kind,
+			Pattern:   postFilter.Pattern,
+			CreatedAt: postFilter.CreatedAt,
+		}
+		if postFilter.FeedID.Valid {
+			record.FeedID = &postFilter.FeedID.UUID
+		}
+		if err := w.write(backupTypePostFilter, record); err != nil {
+			return err
+		}
+	}
+
+	rules, err := queries.BackupRules(ctx)
+	if err != nil {
+		return err
+	}
+	for _, rule := range rules {
+		err := w.write(backupTypeRule, backupRule{
+			ID:         rule.ID,
+			UserID:     rule.UserID,
+			Name:       rule.Name,
+			Expression: rule.Expression,
+			CreatedAt:  rule.CreatedAt,
+			UpdatedAt:  rule.UpdatedAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	postTags, err := queries.BackupPostTags(ctx)
+	if err != nil {
+		return err
+	}
+	for _, postTag := range postTags {
+		err := w.write(backupTypePostTag, backupPostTag{
+			UserID:    postTag.UserID,
+			PostID:    postTag.PostID,
+			Tag:       postTag.Tag,
+			CreatedAt: postTag.CreatedAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	folders, err := queries.BackupFolders(ctx)
+	if err != nil {
+		return err
+	}
+	for _, folder := range folders {
+		err := w.write(backupTypeFolder, backupFolder{
+			ID:        folder.ID,
+			UserID:    folder.UserID,
+			Name:      folder.Name,
+			CreatedAt: folder.CreatedAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	folderFeeds, err := queries.BackupFolderFeeds(ctx)
+	if err != nil {
+		return err
+	}
+	for _, folderFeed := range folderFeeds {
+		err := w.write(backupTypeFolderFeed, backupFolderFeed{
+			FolderID:  folderFeed.FolderID,
+			FeedID:    folderFeed.FeedID,
+			CreatedAt: folderFeed.CreatedAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	webhooks, err := queries.BackupWebhooks(ctx)
+	if err != nil {
+		return err
+	}
+	for _, hook := range webhooks {
+		record := backupWebhook{
+			ID:        hook.ID,
+			UserID:    hook.UserID,
+			Name:      hook.Name,
+			Url:       hook.Url,
+			Format:    hook.Format,
+			Secret:    hook.Secret,
+			CreatedAt: hook.CreatedAt,
+		}
+		if hook.FeedID.Valid {
+			record.FeedID = &hook.FeedID.UUID
+		}
+		if hook.Tag.Valid {
+			record.Tag = &hook.Tag.String
+		}
+		if err := w.write(backupTypeWebhook, record); err != nil {
+			return err
+		}
+	}
+
+	subscriptions, err := queries.BackupDigestSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+	for _, subscription := range subscriptions {
+		err := w.write(backupTypeDigestSubscription, backupDigestSubscription{
+			UserID:    subscription.UserID,
+			Email:     subscription.Email,
+			Frequency: subscription.Frequency,
+			CreatedAt: subscription.CreatedAt,
+			UpdatedAt: subscription.UpdatedAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	digests, err := queries.BackupDigests(ctx)
+	if err != nil {
+		return err
+	}
+	for _, digest := range digests {
+		err := w.write(backupTypeDigest, backupDigest{
+			ID:        digest.ID,
+			UserID:    digest.UserID,
+			Email:     digest.Email,
+			PostCount: digest.PostCount,
+			SentAt:    digest.SentAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	digestPosts, err := queries.BackupDigestPosts(ctx)
+	if err != nil {
+		return err
+	}
+	for _, digestPost := range digestPosts {
+		err := w.write(backupTypeDigestPost, backupDigestPost{
+			DigestID: digestPost.DigestID,
+			PostID:   digestPost.PostID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/database"
+	"github.com/google/uuid"
+)
+
+func writeTestBackup(t *testing.T, s *state) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := &backupWriter{
+		encoder: json.NewEncoder(&buf),
+		counts:  map[string]int{},
+	}
+	if err := writeBackup(s.database, w); err != nil {
+		t.Fatalf("writeBackup failed: %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+// restoreTestBackup restores the rows of an archive written by
+// writeTestBackup, which has no header line.
+func restoreTestBackup(t *testing.T, s *state, archive []byte) *restorer {
+	t.Helper()
+
+	r := newRestorer(s.database)
+	scanner := bufio.NewScanner(bytes.NewReader(archive))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxBackupLineSize)
+	for scanner.Scan() {
+		var record backupRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("invalid backup line %q: %v", scanner.Text(), err)
+		}
+		if err := r.restore(record); err != nil {
+			t.Fatalf("failed to restore %v: %v", record.Type, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("failed to read backup: %v", err)
+	}
+
+	return r
+}
+
+func TestBackupRestoresWebhooks(t *testing.T) {
+	source := newTestState(t)
+	fixture := createTestFixture(t, source, "alice", 1)
+
+	hook, err := source.database.CreateWebhook(context.Background(), database.CreateWebhookParams{
+		ID:        uuid.New(),
+		UserID:    fixture.user.ID,
+		Name:      "go posts",
+		Url:       "https://hooks.example.com/gator",
+		Format:    "slack",
+		Secret:    "s3cret",
+		FeedID:    uuid.NullUUID{UUID: fixture.feed.ID, Valid: true},
+		Tag:       sql.NullString{String: "golang", Valid: true},
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+	})
+	if err != nil {
+		t.Fatalf("failed to create webhook: %v", err)
+	}
+
+	archive := writeTestBackup(t, source)
+
+	target := newTestState(t)
+	r := restoreTestBackup(t, target, archive)
+	if count := r.counts[backupTypeWebhook]; count == nil || count.restored != 1 {
+		t.Fatalf("webhook counts = %+v, want 1 restored", count)
+	}
+
+	user, err := target.database.FindUserByeName(context.Background(), fixture.user.Name)
+	if err != nil {
+		t.Fatalf("failed to find restored user: %v", err)
+	}
+	restored, err := target.database.FindWebhookByName(context.Background(), database.FindWebhookByNameParams{
+		UserID: user.ID,
+		Name:   hook.Name,
+	})
+	if err != nil {
+		t.Fatalf("failed to find restored webhook: %v", err)
+	}
+
+	if restored.Url != hook.Url || restored.Format != hook.Format || restored.Secret != hook.Secret || restored.Tag != hook.Tag {
+		t.Errorf("restored webhook = %+v, want %+v", restored, hook)
+	}
+	if restored.FeedID != (uuid.NullUUID{UUID: r.feeds[fixture.feed.ID], Valid: true}) {
+		t.Errorf("restored webhook feed = %v, want the restored feed", restored.FeedID)
+	}
+
+	// Restoring the archive again skips the existing webhook
+	r = restoreTestBackup(t, target, archive)
+	if count := r.counts[backupTypeWebhook]; count == nil || count.skipped != 1 {
+		t.Errorf("webhook counts of the second restore = %+v, want 1 skipped", count)
+	}
+}
+
+func TestBackupRestoresDigests(t *testing.T) {
+	source := newTestState(t)
+	fixture := createTestFixture(t, source, "alice", 3)
+
+	now := time.Now()
+	subscription := subscribeTestDigest(t, source, fixture, now)
+	digest, err := composeDigest(source, subscription, fixture.user.Name, now)
+	if err != nil {
+		t.Fatalf("composeDigest failed: %v", err)
+	}
+	if err := recordDigest(source, subscription, digest, now); err != nil {
+		t.Fatalf("recordDigest failed: %v", err)
+	}
+
+	target := newTestState(t)
+	r := restoreTestBackup(t, target, writeTestBackup(t, source))
+
+	wantCounts := map[string]int{
+		backupTypeDigestSubscription: 1,
+		backupTypeDigest:             1,
+		backupTypeDigestPost:         len(fixture.posts),
+	}
+	for recordType, want := range wantCounts {
+		if count := r.counts[recordType]; count == nil || count.restored != want {
+			t.Errorf("%v counts = %+v, want %v restored", recordType, count, want)
+		}
+	}
+
+	user, err := target.database.FindUserByeName(context.Background(), fixture.user.Name)
+	if err != nil {
+		t.Fatalf("failed to find restored user: %v", err)
+	}
+	restored, err := target.database.FindDigestSubscription(context.Background(), user.ID)
+	if err != nil {
+		t.Fatalf("failed to find restored subscription: %v", err)
+	}
+	if restored.Email != subscription.Email || restored.Frequency != subscription.Frequency {
+		t.Errorf("restored subscription = %+v, want %+v", restored, subscription)
+	}
+
+	// The posts already sent are not sent again from the restored database
+	next, err := composeDigest(target, restored, user.Name, now)
+	if err != nil {
+		t.Fatalf("composeDigest failed: %v", err)
+	}
+	if len(next.PostIDs) != 0 {
+		t.Errorf("digest after restoring has %v posts, want 0", len(next.PostIDs))
+	}
+}
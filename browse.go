@@ -0,0 +1,301 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPostLimit = 2
+	sortByPublished  = "published"
+	sortByFetched    = "fetched"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+const browseUsage = "Usage: gator browse [limit] [--limit n] [--page n | --offset n | --cursor c] [--sort published|fetched] [--feed name|url] [--author name] [--since 24h|2006-01-02] [--before 24h|2006-01-02] [--unread] [--saved name] [--folder name] [--tag tag] [--show-filtered]"
+
+type browseOptions struct {
+	limit  int
+	page   int
+	offset int
+	cursor string
+	sortBy string
+	feed   string
+	author string
+	since  string
+	before string
+	unread bool
+	saved  string
+	query  string
+	folder string
+	tag    string
+
+	showFiltered bool
+}
+
+// parseBrowseArgs parses the arguments of the browse command. The limit can
+// still be passed as the first positional argument for backwards
+// compatibility, e.g. "gator browse 10 --since 24h".
+func parseBrowseArgs(args []string) (browseOptions, error) {
+	opts := browseOptions{}
+
+	positionalLimit := 0
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		limit, err := strconv.Atoi(args[0])
+		if err != nil {
+			return opts, fmt.Errorf("failed to convert post limit to int: %v", err)
+		}
+		positionalLimit = limit
+		args = args[1:]
+	}
+
+	flags := newFlagSet("browse")
+	flags.IntVar(&opts.limit, "limit", 0, "number of posts to show")
+	flags.IntVar(&opts.page, "page", 0, "page number (starting at 1)")
+	flags.IntVar(&opts.offset, "offset", 0, "number of posts to skip")
+	flags.StringVar(&opts.cursor, "cursor", "", "continue from a cursor printed by a previous browse")
+	flags.StringVar(&opts.sortBy, "sort", sortByPublished, "sort by \"published\" or \"fetched\" time")
+	flags.StringVar(&opts.feed, "feed", "", "only show posts of the feed with this name or URL")
+	flags.StringVar(&opts.author, "author", "", "only show posts by this author")
+	flags.StringVar(&opts.since, "since", "", "only show posts newer than a duration (24h, 7d) or date (2006-01-02)")
+	flags.StringVar(&opts.before, "before", "", "only show posts older than a duration (24h, 7d) or date (2006-01-02)")
+	flags.BoolVar(&opts.unread, "unread", false, "only show posts which have not been read yet")
+	flags.StringVar(&opts.saved, "saved", "", "only show posts matching the saved search with this name")
+	flags.StringVar(&opts.folder, "folder", "", "only show posts of the feeds in this folder")
+	flags.StringVar(&opts.tag, "tag", "", "only show posts with this tag")
+	flags.BoolVar(&opts.showFiltered, "show-filtered", false, "also show posts hidden by your filters")
+
+	if err := flags.Parse(args); err != nil {
+		return opts, fmt.Errorf("%v\n%v", err, browseUsage)
+	}
+	if flags.NArg() > 0 {
+		return opts, fmt.Errorf("unexpected arguments: %v\n%v", strings.Join(flags.Args(), " "), browseUsage)
+	}
+
+	if opts.limit == 0 {
+		opts.limit = positionalLimit
+	}
+	if opts.limit == 0 {
+		opts.limit = defaultPostLimit
+	}
+	if opts.limit < 0 {
+		return opts, fmt.Errorf("the limit must be a positive number")
+	}
+	if opts.page < 0 || opts.offset < 0 {
+		return opts, fmt.Errorf("the page and offset must be positive numbers")
+	}
+	if opts.page > 0 && opts.offset > 0 {
+		return opts, fmt.Errorf("--page and --offset cannot be used together")
+	}
+	if opts.cursor != "" && (opts.page > 0 || opts.offset > 0) {
+		return opts, fmt.Errorf("--cursor cannot be combined with --page or --offset")
+	}
+	if opts.sortBy != sortByPublished && opts.sortBy != sortByFetched {
+		return opts, fmt.Errorf("invalid sort %q, valid values are %q and %q", opts.sortBy, sortByPublished, sortByFetched)
+	}
+
+	return opts, nil
+}
+
+// parseTimeFilter parses either a duration relative to now (e.g. "90m",
+// "24h", "7d", "2w") or an absolute date ("2006-01-02" or RFC3339).
+func parseTimeFilter(value string, now time.Time) (time.Time, error) {
+	if duration, err := parseDuration(value); err == nil {
+		return now.Add(-duration), nil
+	}
+
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("unable to parse %q as a duration (24h, 7d) or date (2006-01-02)", value)
+}
+
+// parseDuration extends time.ParseDuration with day ("d") and week ("w")
+// units.
+func parseDuration(value string) (time.Duration, error) {
+	units := map[string]time.Duration{
+		"d": 24 * time.Hour,
+		"w": 7 * 24 * time.Hour,
+	}
+
+	for suffix, unit := range units {
+		if amount, found := strings.CutSuffix(value, suffix); found {
+			n, err := strconv.Atoi(amount)
+			if err != nil {
+				return 0, err
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+
+	return time.ParseDuration(value)
+}
+
+// encodeCursor builds an opaque keyset pagination cursor pointing at the
+// given post. The sort order is part of the cursor, the sort key of one order
+// means nothing in the other.
+func encodeCursor(sortBy string, sortKey time.Time, postID uuid.UUID) string {
+	raw := fmt.Sprintf("%s|%d|%s", sortBy, sortKey.UnixNano(), postID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(cursor string, sortBy string) (time.Time, uuid.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, uuid.Nil, ErrInvalidCursor
+	}
+
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 3 {
+		return time.Time{}, uuid.Nil, ErrInvalidCursor
+	}
+	if parts[0] != sortBy {
+		return time.Time{}, uuid.Nil, fmt.Errorf("%w: the cursor belongs to the %q sort", ErrInvalidCursor, parts[0])
+	}
+	nanosStr, idStr := parts[1], parts[2]
+
+	nanos, err := strconv.ParseInt(nanosStr, 10, 64)
+	if err != nil {
+		return time.Time{}, uuid.Nil, ErrInvalidCursor
+	}
+
+	postID, err := uuid.Parse(idStr)
+	if err != nil {
+		return time.Time{}, uuid.Nil, ErrInvalidCursor
+	}
+
+	return time.Unix(0, nanos), postID, nil
+}
+
+func nullString(value string) sql.NullString {
+	return sql.NullString{
+		String: value,
+		Valid:  value != "",
+	}
+}
+
+// buildPostsQuery turns the browse options into the parameters of the
+// GetPostsForUser query.
+func buildPostsQuery(userID uuid.UUID, opts browseOptions, now time.Time) (database.GetPostsForUserParams, error) {
+	params := database.GetPostsForUserParams{
+		UserID:       userID,
+		Feed:         nullString(opts.feed),
+		Author:       nullString(opts.author),
+		UnreadOnly:   opts.unread,
+		Query:        nullString(opts.query),
+		Folder:       nullString(opts.folder),
+		Tag:          nullString(opts.tag),
+		ShowFiltered: opts.showFiltered,
+		Limit:        int32(opts.limit),
+		Offset:       int32(opts.offset),
+	}
+
+	if opts.page > 0 {
+		params.Offset = int32((opts.page - 1) * opts.limit)
+	}
+
+	if opts.since != "" {
+		since, err := parseTimeFilter(opts.since, now)
+		if err != nil {
+			return params, fmt.Errorf("invalid --since value: %v", err)
+		}
+		params.Since = sql.NullTime{Time: since, Valid: true}
+	}
+
+	if opts.before != "" {
+		before, err := parseTimeFilter(opts.before, now)
+		if err != nil {
+			return params, fmt.Errorf("invalid --before value: %v", err)
+		}
+		params.Before = sql.NullTime{Time: before, Valid: true}
+	}
+
+	if opts.cursor != "" {
+		cursorTime, cursorID, err := decodeCursor(opts.cursor, opts.sortBy)
+		if err != nil {
+			return params, fmt.Errorf("failed to read --cursor: %v", err)
+		}
+		params.CursorTime = sql.NullTime{Time: cursorTime, Valid: true}
+		params.CursorID = uuid.NullUUID{UUID: cursorID, Valid: true}
+	}
+
+	return params, nil
+}
+
+// getPostsForUser runs the posts query of the sort order. Each order has its
+// own query so Postgres can read the posts in order from an index.
+func getPostsForUser(ctx context.Context, queries *database.Queries, sortBy string, params database.GetPostsForUserParams) ([]database.GetPostsForUserRow, error) {
+	if sortBy != sortByFetched {
+		return queries.GetPostsForUser(ctx, params)
+	}
+
+	fetchedPosts, err := queries.GetPostsForUserByFetched(ctx, database.GetPostsForUserByFetchedParams(params))
+	if err != nil {
+		return nil, err
+	}
+
+	userPosts := make([]database.GetPostsForUserRow, 0, len(fetchedPosts))
+	for _, post := range fetchedPosts {
+		userPosts = append(userPosts, database.GetPostsForUserRow(post))
+	}
+
+	return userPosts, nil
+}
+
+func handleBrowse(s *state, cmd command, user database.User) error {
+	opts, err := parseBrowseArgs(cmd.args)
+	if err != nil {
+		return err
+	}
+
+	if opts.saved != "" {
+		savedSearch, err := findSavedSearch(s, user.ID, opts.saved)
+		if err != nil {
+			return err
+		}
+		opts.query = savedSearch.Query
+	}
+
+	params, err := buildPostsQuery(user.ID, opts, time.Now())
+	if err != nil {
+		return err
+	}
+
+	userPosts, err := getPostsForUser(context.Background(), s.database, opts.sortBy, params)
+	if err != nil {
+		return fmt.Errorf("failed to get posts for the user: %v", err)
+	}
+
+	if len(userPosts) == 0 {
+		fmt.Println("No posts found")
+		return nil
+	}
+
+	width := boxWidth()
+	for _, post := range userPosts {
+		renderPost(os.Stdout, postViewFromUserPost(post), width)
+	}
+
+	if len(userPosts) == opts.limit {
+		lastPost := userPosts[len(userPosts)-1]
+		fmt.Printf("More posts available, repeat the command with: --cursor %v\n", encodeCursor(opts.sortBy, lastPost.SortKey, lastPost.ID))
+	}
+
+	return nil
+}
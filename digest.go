@@ -0,0 +1,487 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"log/slog"
+	"net/mail"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/config"
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/database"
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/email"
+	"github.com/google/uuid"
+)
+
+const (
+	digestDaily  = "daily"
+	digestWeekly = "weekly"
+
+	// Posts in a single digest, the rest waits for the next one
+	digestMaxPosts = 100
+
+	// A digest is due a little early so a daily cron job does not skip a day
+	// when the previous run took longer
+	digestDueSlack = time.Hour
+)
+
+var digestPeriods = map[string]time.Duration{
+	digestDaily:  24 * time.Hour,
+	digestWeekly: 7 * 24 * time.Hour,
+}
+
+var ErrNoDigestSubscription = errors.New("not subscribed to digests")
+var ErrSMTPNotConfigured = errors.New("SMTP is not configured")
+
+const digestUsage = `Usage:
+  gator digest subscribe <daily|weekly> <email>
+  gator digest unsubscribe
+  gator digest status
+  gator digest preview
+  gator digest send [--user name] [--force] [--dry-run]`
+
+// Digests email the unread posts of the followed feeds which were not in a
+// previous digest. "digest send" sends the digests which are due and is meant
+// to run from cron, the other sub commands manage the digest of the current
+// user.
+func handleDigest(s *state, cmd command) error {
+	if len(cmd.args) == 0 {
+		return fmt.Errorf("the digest command requires a sub command.\n%v", digestUsage)
+	}
+
+	subCmd := command{
+		name: cmd.name + " " + cmd.args[0],
+		args: cmd.args[1:],
+	}
+
+	switch cmd.args[0] {
+	case "subscribe":
+		return middlewareLoggedIn(handleDigestSubscribe)(s, subCmd)
+	case "unsubscribe":
+		return middlewareLoggedIn(handleDigestUnsubscribe)(s, subCmd)
+	case "status":
+		return middlewareLoggedIn(handleDigestStatus)(s, subCmd)
+	case "preview":
+		return middlewareLoggedIn(handleDigestPreview)(s, subCmd)
+	case "send":
+		return handleDigestSend(s, subCmd)
+	}
+
+	return fmt.Errorf("unknown digest sub command: %v\n%v", cmd.args[0], digestUsage)
+}
+
+func findDigestSubscription(s *state, userID uuid.UUID) (database.DigestSubscription, error) {
+	subscription, err := s.database.FindDigestSubscription(context.Background(), userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return database.DigestSubscription{}, fmt.Errorf("%w. Use: gator digest subscribe <daily|weekly> <email>", ErrNoDigestSubscription)
+		}
+
+		return database.DigestSubscription{}, fmt.Errorf("failed to find digest subscription: %v", err)
+	}
+
+	return subscription, nil
+}
+
+func handleDigestSubscribe(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 2 {
+		return fmt.Errorf("the digest subscribe command requires a frequency and an email address.\n%v", digestUsage)
+	}
+
+	frequency := cmd.args[0]
+	if _, ok := digestPeriods[frequency]; !ok {
+		return fmt.Errorf("invalid frequency %q, valid values are %q and %q", frequency, digestDaily, digestWeekly)
+	}
+
+	address, err := mail.ParseAddress(cmd.args[1])
+	if err != nil {
+		return fmt.Errorf("invalid email address %q: %v", cmd.args[1], err)
+	}
+
+	now := time.Now()
+	subscription, err := s.database.SetDigestSubscription(context.Background(), database.SetDigestSubscriptionParams{
+		UserID:    user.ID,
+		Email:     address.Address,
+		Frequency: frequency,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save digest subscription: %v", err)
+	}
+
+	fmt.Printf("%v will receive a %v digest at %v\n", user.Name, subscription.Frequency, subscription.Email)
+	fmt.Println("Digests are sent by \"gator digest send\", run it from cron (e.g. every hour)")
+
+	return nil
+}
+
+func handleDigestUnsubscribe(s *state, cmd command, user database.User) error {
+	removedCount, err := s.database.DeleteDigestSubscription(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to remove digest subscription: %v", err)
+	}
+
+	if removedCount == 0 {
+		return ErrNoDigestSubscription
+	}
+
+	fmt.Printf("%v no longer receives digests\n", user.Name)
+
+	return nil
+}
+
+func handleDigestStatus(s *state, cmd command, user database.User) error {
+	subscription, err := findDigestSubscription(s, user.ID)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Digest of %v\n", user.Name)
+	fmt.Printf("- Email: %v\n", subscription.Email)
+	fmt.Printf("- Frequency: %v\n", subscription.Frequency)
+
+	lastDigest, err := s.database.GetLastDigest(context.Background(), user.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to get last digest: %v", err)
+	}
+
+	if err == nil {
+		fmt.Printf("- Last sent: %v (%v posts)\n", lastDigest.SentAt.Format("02 January 2006 15:04"), lastDigest.PostCount)
+		fmt.Printf("- Next due: %v\n", digestDueAt(subscription.Frequency, lastDigest.SentAt).Format("02 January 2006 15:04"))
+	} else {
+		fmt.Printf("- Last sent: never\n")
+		fmt.Printf("- Next due: on the next \"gator digest send\"\n")
+	}
+
+	return nil
+}
+
+// handleDigestPreview prints the text of the next digest without sending or
+// recording it.
+func handleDigestPreview(s *state, cmd command, user database.User) error {
+	subscription, err := findDigestSubscription(s, user.ID)
+	if err != nil {
+		return err
+	}
+
+	digest, err := composeDigest(s, subscription, user.Name, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if len(digest.PostIDs) == 0 {
+		fmt.Println("No new posts for the next digest")
+		return nil
+	}
+
+	message, err := digest.message("", subscription.Email)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Subject: %v\n\n%v", message.Subject, message.Text)
+
+	return nil
+}
+
+// digestDueAt is when the next digest is due after one sent at lastSentAt.
+func digestDueAt(frequency string, lastSentAt time.Time) time.Time {
+	return lastSentAt.Add(digestPeriods[frequency] - digestDueSlack)
+}
+
+// handleDigestSend sends the due digests of every subscribed user, or of a
+// single user with --user. A digest is recorded together with its posts once
+// the mail server accepted it, so a failed send is retried by the next run.
+func handleDigestSend(s *state, cmd command) error {
+	flags := newFlagSet(cmd.name)
+	userName := flags.String("user", "", "only send the digest of this user")
+	force := flags.Bool("force", false, "send even when the digest is not due yet")
+	dryRun := flags.Bool("dry-run", false, "show which digests would be sent without sending them")
+
+	if _, err := parseFlags(flags, cmd.args); err != nil {
+		return fmt.Errorf("%v\n%v", err, digestUsage)
+	}
+
+	smtpConfig := s.config.SMTP
+	if smtpConfig == nil && !*dryRun {
+		return fmt.Errorf("%w, add an \"smtp\" section to ~/.gatorconfig.json (see the README)", ErrSMTPNotConfigured)
+	}
+
+	userFilter := sql.NullString{}
+	if *userName != "" {
+		userFilter = sql.NullString{String: *userName, Valid: true}
+	}
+
+	subscriptions, err := s.database.GetDigestSubscriptions(context.Background(), userFilter)
+	if err != nil {
+		return fmt.Errorf("failed to get digest subscriptions: %v", err)
+	}
+
+	if len(subscriptions) == 0 {
+		fmt.Println("No digest subscriptions found")
+		return nil
+	}
+
+	now := time.Now()
+	sentCount := 0
+	failedCount := 0
+	for _, row := range subscriptions {
+		if row.LastSentAt.Valid && !*force && now.Before(digestDueAt(row.Frequency, row.LastSentAt.Time)) {
+			fmt.Printf("%v: not due until %v\n", row.UserName, digestDueAt(row.Frequency, row.LastSentAt.Time).Format("02 January 2006 15:04"))
+			continue
+		}
+
+		subscription := database.DigestSubscription{
+			UserID:    row.UserID,
+			Email:     row.Email,
+			Frequency: row.Frequency,
+			CreatedAt: row.CreatedAt,
+			UpdatedAt: row.UpdatedAt,
+		}
+
+		digest, err := composeDigest(s, subscription, row.UserName, now)
+		if err != nil {
+			return err
+		}
+
+		if len(digest.PostIDs) == 0 {
+			fmt.Printf("%v: no new posts\n", row.UserName)
+			continue
+		}
+
+		if *dryRun {
+			fmt.Printf("%v: would send %v posts to %v\n", row.UserName, len(digest.PostIDs), row.Email)
+			continue
+		}
+
+		if err := sendDigest(s, *smtpConfig, subscription, digest, now); err != nil {
+			// One bad address must not hold up the digests of other users
+			slog.Error("failed to send digest", "user", row.UserName, "email", row.Email, "posts", len(digest.PostIDs), "error", err)
+			failedCount++
+			continue
+		}
+
+		fmt.Printf("%v: sent %v posts to %v\n", row.UserName, len(digest.PostIDs), row.Email)
+		sentCount++
+	}
+
+	if !*dryRun {
+		fmt.Printf("Digests sent: %v, failed: %v\n", sentCount, failedCount)
+	}
+
+	if failedCount > 0 {
+		return fmt.Errorf("failed to send %v digest(s)", failedCount)
+	}
+
+	return nil
+}
+
+// sendDigest sends the digest and then records it with its posts. No
+// transaction is open while talking to the mail server, a slow server must not
+// hold locks on the digest history.
+func sendDigest(s *state, smtpConfig config.SMTPConfig, subscription database.DigestSubscription, digest digestContent, now time.Time) error {
+	message, err := digest.message(smtpConfig.From, subscription.Email)
+	if err != nil {
+		return err
+	}
+
+	err = email.Send(email.Server{
+		Host:     smtpConfig.Host,
+		Port:     smtpConfig.Port,
+		Username: smtpConfig.Username,
+		Password: smtpConfig.Password,
+		TLS:      smtpConfig.TLS,
+	}, message)
+	if err != nil {
+		return err
+	}
+
+	if err := recordDigest(s, subscription, digest, now); err != nil {
+		return fmt.Errorf("the digest was sent but %v, its posts will be sent again", err)
+	}
+
+	return nil
+}
+
+func recordDigest(s *state, subscription database.DigestSubscription, digest digestContent, now time.Time) error {
+	tx, err := s.db.BeginTx(context.Background(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	queries := s.database.WithTx(tx)
+
+	sent, err := queries.CreateDigest(context.Background(), database.CreateDigestParams{
+		ID:        uuid.New(),
+		UserID:    subscription.UserID,
+		Email:     subscription.Email,
+		PostCount: int32(len(digest.PostIDs)),
+		SentAt:    now,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record digest: %v", err)
+	}
+
+	for _, postID := range digest.PostIDs {
+		err := queries.CreateDigestPost(context.Background(), database.CreateDigestPostParams{
+			DigestID: sent.ID,
+			PostID:   postID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to record digest post: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit digest: %v", err)
+	}
+
+	return nil
+}
+
+type digestPost struct {
+	Title     string
+	URL       string
+	Author    string
+	Published string
+	Summary   string
+}
+
+type digestFeed struct {
+	Name  string
+	Posts []digestPost
+}
+
+type digestContent struct {
+	UserName  string
+	Frequency string
+	Feeds     []digestFeed
+	PostIDs   []uuid.UUID
+	// More posts are waiting than fit into the digest
+	More bool
+}
+
+// composeDigest collects the posts of the next digest of the subscription:
+// unread posts of the followed feeds which were not sent yet. The first
+// digest covers the period before subscribing.
+func composeDigest(s *state, subscription database.DigestSubscription, userName string, now time.Time) (digestContent, error) {
+	posts, err := s.database.GetDigestPosts(context.Background(), database.GetDigestPostsParams{
+		UserID: subscription.UserID,
+		Since:  subscription.CreatedAt.Add(-digestPeriods[subscription.Frequency]),
+		Limit:  digestMaxPosts + 1,
+	})
+	if err != nil {
+		return digestContent{}, fmt.Errorf("failed to get digest posts: %v", err)
+	}
+
+	digest := digestContent{
+		UserName:  userName,
+		Frequency: subscription.Frequency,
+	}
+	if len(posts) > digestMaxPosts {
+		posts = posts[:digestMaxPosts]
+		digest.More = true
+	}
+
+	// Posts are grouped by feed, the feed with the newest post first
+	feedIndex := map[string]int{}
+	for _, post := range posts {
+		published := post.CreatedAt
+		if post.PublishedAt.Valid {
+			published = post.PublishedAt.Time
+		}
+
+		index, ok := feedIndex[post.FeedName]
+		if !ok {
+			index = len(digest.Feeds)
+			feedIndex[post.FeedName] = index
+			digest.Feeds = append(digest.Feeds, digestFeed{Name: post.FeedName})
+		}
+
+		digest.Feeds[index].Posts = append(digest.Feeds[index].Posts, digestPost{
+			Title:     post.Title,
+			URL:       post.Url,
+			Author:    post.Author,
+			Published: published.Format("02 January 2006 15:04"),
+			Summary:   excerpt(post.Description),
+		})
+		digest.PostIDs = append(digest.PostIDs, post.ID)
+	}
+
+	return digest, nil
+}
+
+var digestTextTemplate = texttemplate.Must(texttemplate.New("digest").Parse(`Your {{.Frequency}} gator digest, {{.UserName}}
+{{range .Feeds}}
+== {{.Name}} ==
+{{range .Posts}}
+* {{.Title}}
+  {{.Published}}{{if .Author}} by {{.Author}}{{end}}
+  {{.URL}}
+{{- if .Summary}}
+  {{.Summary}}
+{{- end}}
+{{end}}{{end}}
+{{- if .More}}
+More unread posts are waiting in gator, they will be in the next digest.
+{{end}}
+--
+Unsubscribe with: gator digest unsubscribe
+`))
+
+var digestHTMLTemplate = htmltemplate.Must(htmltemplate.New("digest").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Your {{.Frequency}} gator digest</title>
+</head>
+<body style="font-family: sans-serif; max-width: 40em; margin: 0 auto; color: #222;">
+<h1 style="font-size: 1.4em;">Your {{.Frequency}} gator digest, {{.UserName}}</h1>
+{{range .Feeds}}
+<h2 style="font-size: 1.15em; border-bottom: 1px solid #ddd;">{{.Name}}</h2>
+{{range .Posts}}
+<div style="margin-bottom: 1em;">
+<a href="{{.URL}}" style="font-weight: bold;">{{.Title}}</a>
+<div style="color: #666; font-size: 0.9em;">{{.Published}}{{if .Author}} by {{.Author}}{{end}}</div>
+{{if .Summary}}<p style="margin: 0.3em 0;">{{.Summary}}</p>{{end}}
+</div>
+{{end}}
+{{end}}
+{{if .More}}<p>More unread posts are waiting in gator, they will be in the next digest.</p>{{end}}
+<p style="color: #666; font-size: 0.9em;">Unsubscribe with <code>gator digest unsubscribe</code>.</p>
+</body>
+</html>
+`))
+
+func (d digestContent) message(from string, to string) (*email.Message, error) {
+	var text bytes.Buffer
+	if err := digestTextTemplate.Execute(&text, d); err != nil {
+		return nil, fmt.Errorf("failed to render digest: %v", err)
+	}
+
+	var html bytes.Buffer
+	if err := digestHTMLTemplate.Execute(&html, d); err != nil {
+		return nil, fmt.Errorf("failed to render digest: %v", err)
+	}
+
+	posts := "posts"
+	if len(d.PostIDs) == 1 {
+		posts = "post"
+	}
+
+	return &email.Message{
+		From:    from,
+		To:      to,
+		Subject: fmt.Sprintf("Your %v gator digest: %v new %v", d.Frequency, len(d.PostIDs), posts),
+		Text:    strings.TrimLeft(text.String(), "\n"),
+		HTML:    html.String(),
+	}, nil
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/config"
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/database"
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/email"
+)
+
+// startTestSMTPServer accepts every command. With reject it refuses the
+// message after DATA, the way a server fails on a bad recipient or a full
+// mailbox.
+func startTestSMTPServer(t *testing.T, reject bool) config.SMTPConfig {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+				conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+				reader := bufio.NewReader(conn)
+				io.WriteString(conn, "220 localhost ESMTP test\r\n")
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+
+					switch verb := strings.ToUpper(strings.TrimSpace(line)); {
+					case verb == "DATA":
+						io.WriteString(conn, "354 go ahead\r\n")
+						for line != ".\r\n" {
+							if line, err = reader.ReadString('\n'); err != nil {
+								return
+							}
+						}
+						if reject {
+							io.WriteString(conn, "554 mailbox unavailable\r\n")
+						} else {
+							io.WriteString(conn, "250 OK\r\n")
+						}
+					case verb == "QUIT":
+						io.WriteString(conn, "221 bye\r\n")
+						return
+					default:
+						io.WriteString(conn, "250 OK\r\n")
+					}
+				}
+			}()
+		}
+	}()
+
+	return config.SMTPConfig{
+		Host: "127.0.0.1",
+		Port: listener.Addr().(*net.TCPAddr).Port,
+		From: "gator@example.com",
+		TLS:  email.TLSNone,
+	}
+}
+
+func subscribeTestDigest(t *testing.T, s *state, fixture testFixture, now time.Time) database.DigestSubscription {
+	t.Helper()
+
+	subscription, err := s.database.SetDigestSubscription(context.Background(), database.SetDigestSubscriptionParams{
+		UserID:    fixture.user.ID,
+		Email:     fixture.user.Name + "@example.com",
+		Frequency: digestDaily,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+
+	return subscription
+}
+
+func countDigestRows(t *testing.T, s *state) map[string]int {
+	t.Helper()
+
+	counts := map[string]int{}
+	for _, table := range []string{"digests", "digest_posts"} {
+		var count int
+		if err := s.db.QueryRow("SELECT count(*) FROM " + table).Scan(&count); err != nil {
+			t.Fatalf("failed to count %v: %v", table, err)
+		}
+		counts[table] = count
+	}
+
+	return counts
+}
+
+func TestSendDigestRecordsNothingOnFailedSend(t *testing.T) {
+	s := newTestState(t)
+	fixture := createTestFixture(t, s, "alice", 3)
+	smtpConfig := startTestSMTPServer(t, true)
+
+	now := time.Now()
+	subscription := subscribeTestDigest(t, s, fixture, now)
+
+	digest, err := composeDigest(s, subscription, fixture.user.Name, now)
+	if err != nil {
+		t.Fatalf("composeDigest failed: %v", err)
+	}
+	if len(digest.PostIDs) != len(fixture.posts) {
+		t.Fatalf("digest has %v posts, want %v", len(digest.PostIDs), len(fixture.posts))
+	}
+
+	err = sendDigest(s, smtpConfig, subscription, digest, now)
+	if err == nil {
+		t.Fatal("sendDigest succeeded, want the rejection of the server")
+	}
+	if !strings.Contains(err.Error(), "554") {
+		t.Errorf("sendDigest error = %q, want the 554 reply", err)
+	}
+
+	for table, count := range countDigestRows(t, s) {
+		if count != 0 {
+			t.Errorf("%v has %v rows after a failed send, want 0", table, count)
+		}
+	}
+
+	// The posts are still due, so the next run retries them
+	retry, err := composeDigest(s, subscription, fixture.user.Name, now)
+	if err != nil {
+		t.Fatalf("composeDigest failed: %v", err)
+	}
+	if len(retry.PostIDs) != len(fixture.posts) {
+		t.Errorf("retried digest has %v posts, want %v", len(retry.PostIDs), len(fixture.posts))
+	}
+}
+
+func TestSendDigestRecordsSentDigest(t *testing.T) {
+	s := newTestState(t)
+	fixture := createTestFixture(t, s, "alice", 3)
+	smtpConfig := startTestSMTPServer(t, false)
+
+	now := time.Now()
+	subscription := subscribeTestDigest(t, s, fixture, now)
+
+	digest, err := composeDigest(s, subscription, fixture.user.Name, now)
+	if err != nil {
+		t.Fatalf("composeDigest failed: %v", err)
+	}
+
+	if err := sendDigest(s, smtpConfig, subscription, digest, now); err != nil {
+		t.Fatalf("sendDigest failed: %v", err)
+	}
+
+	counts := countDigestRows(t, s)
+	if counts["digests"] != 1 || counts["digest_posts"] != len(fixture.posts) {
+		t.Errorf("rows after sending = %v, want 1 digest with %v posts", counts, len(fixture.posts))
+	}
+
+	// Sent posts are not in the next digest
+	next, err := composeDigest(s, subscription, fixture.user.Name, now)
+	if err != nil {
+		t.Fatalf("composeDigest failed: %v", err)
+	}
+	if len(next.PostIDs) != 0 {
+		t.Errorf("next digest has %v posts, want 0", len(next.PostIDs))
+	}
+}
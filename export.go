@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/database"
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/opml"
+	"github.com/google/uuid"
+)
+
+const exportUsage = `Usage:
+  gator export opml [file]`
+
+func handleExport(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return fmt.Errorf("the export command requires a format.\n%v", exportUsage)
+	}
+
+	subCmd := command{
+		name: cmd.name + " " + cmd.args[0],
+		args: cmd.args[1:],
+	}
+
+	switch cmd.args[0] {
+	case "opml":
+		return handleExportOPML(s, subCmd, user)
+	}
+
+	return fmt.Errorf("unknown export format: %v\n%v", cmd.args[0], exportUsage)
+}
+
+// handleExportOPML writes the feeds the user follows as an OPML 2.0 document,
+// either to the given file or to stdout. Folders become nested outlines, feeds
+// in several folders are listed in each of them.
+func handleExportOPML(s *state, cmd command, user database.User) error {
+	feedFollows, err := s.database.GetFeedFollowsForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get feed follows for user: %v", err)
+	}
+
+	folderFeeds, err := s.database.GetFolderFeedsForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get folders for user: %v", err)
+	}
+
+	foldersByFeed := map[uuid.UUID][]string{}
+	for _, folderFeed := range folderFeeds {
+		foldersByFeed[folderFeed.FeedID] = append(foldersByFeed[folderFeed.FeedID], folderFeed.FolderName)
+	}
+
+	doc := opml.New(fmt.Sprintf("gator subscriptions of %v", user.Name), user.Name, time.Now())
+	for _, feedFollow := range feedFollows {
+		feed := opml.Feed{
+			Title:   feedFollow.FeedName,
+			XMLURL:  feedFollow.FeedUrl,
+			HTMLURL: feedFollow.FeedSiteUrl,
+		}
+
+		folders, ok := foldersByFeed[feedFollow.FeedID]
+		if !ok {
+			doc.AddFeed(feed)
+			continue
+		}
+
+		for _, folder := range folders {
+			feed.Folder = folder
+			doc.AddFeed(feed)
+		}
+	}
+
+	var w io.Writer = os.Stdout
+	var file *os.File
+	if len(cmd.args) > 0 {
+		file, err = os.Create(cmd.args[0])
+		if err != nil {
+			return fmt.Errorf("failed to create OPML file: %v", err)
+		}
+		// Only closes the file on errors, the close below reports write errors
+		defer file.Close()
+
+		w = file
+	}
+
+	if err := doc.Encode(w); err != nil {
+		return fmt.Errorf("failed to write OPML: %v", err)
+	}
+
+	if file != nil {
+		if err := file.Close(); err != nil {
+			return fmt.Errorf("failed to write OPML file: %v", err)
+		}
+
+		fmt.Printf("Exported %v feeds to %v\n", len(feedFollows), cmd.args[0])
+	}
+
+	return nil
+}
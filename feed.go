@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/database"
+	"github.com/lib/pq"
+)
+
+var ErrFeedNotFound = errors.New("feed not found")
+var ErrFeedExists = errors.New("a feed with this URL already exists")
+var ErrNotFeedOwner = errors.New("only the user who added the feed can change it")
+
+const feedUsage = `Usage:
+  gator feed rename <feed_url> <new_name>
+  gator feed seturl <feed_url> <new_url>
+  gator feed delete <feed_url> [--yes]
+  gator feed transfer <feed_url> <user>`
+
+func findFeed(s *state, feedUrl string) (database.Feed, error) {
+	feed, err := s.database.FindFeedByUrl(context.Background(), feedUrl)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return database.Feed{}, fmt.Errorf("%w: %v", ErrFeedNotFound, feedUrl)
+		}
+
+		return database.Feed{}, fmt.Errorf("failed to find feed by URL: %v", err)
+	}
+
+	return feed, nil
+}
+
+// findOwnedFeed looks up the feed by URL and makes sure it has been added by
+// the user, only the owner of a feed may change it.
+func findOwnedFeed(s *state, feedUrl string, user database.User) (database.Feed, error) {
+	feed, err := findFeed(s, feedUrl)
+	if err != nil {
+		return database.Feed{}, err
+	}
+
+	if feed.UserID != user.ID {
+		return database.Feed{}, fmt.Errorf("%w: %v", ErrNotFeedOwner, feed.Name)
+	}
+
+	return feed, nil
+}
+
+func handleFeed(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return fmt.Errorf("the feed command requires a sub command.\n%v", feedUsage)
+	}
+
+	subCmd := command{
+		name: cmd.name + " " + cmd.args[0],
+		args: cmd.args[1:],
+	}
+
+	switch cmd.args[0] {
+	case "rename":
+		return handleFeedRename(s, subCmd, user)
+	case "seturl":
+		return handleFeedSetUrl(s, subCmd, user)
+	case "delete":
+		return handleFeedDelete(s, subCmd, user)
+	case "transfer":
+		return handleFeedTransfer(s, subCmd, user)
+	}
+
+	return fmt.Errorf("unknown feed sub command: %v\n%v", cmd.args[0], feedUsage)
+}
+
+func handleFeedRename(s *state, cmd command, user database.User) error {
+	if len(cmd.args) < 2 {
+		return fmt.Errorf("the feed rename command requires a feed URL and a new name.\n%v", feedUsage)
+	}
+
+	feed, err := findOwnedFeed(s, cmd.args[0], user)
+	if err != nil {
+		return err
+	}
+
+	newName := cmd.args[1]
+	err = s.database.RenameFeed(context.Background(), database.RenameFeedParams{
+		Name: newName,
+		ID:   feed.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to rename feed: %v", err)
+	}
+
+	fmt.Printf("Feed renamed: %v -> %v\n", feed.Name, newName)
+
+	return nil
+}
+
+func handleFeedSetUrl(s *state, cmd command, user database.User) error {
+	if len(cmd.args) < 2 {
+		return fmt.Errorf("the feed seturl command requires the current and the new feed URL.\n%v", feedUsage)
+	}
+
+	feed, err := findOwnedFeed(s, cmd.args[0], user)
+	if err != nil {
+		return err
+	}
+
+	newUrl := cmd.args[1]
+	err = s.database.SetFeedUrl(context.Background(), database.SetFeedUrlParams{
+		Url: newUrl,
+		ID:  feed.ID,
+	})
+	if err != nil {
+		var pqErr *pq.Error
+
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return fmt.Errorf("%w: %v", ErrFeedExists, newUrl)
+		}
+
+		return fmt.Errorf("failed to change feed URL: %v", err)
+	}
+
+	fmt.Printf("Feed URL of %v changed: %v -> %v\n", feed.Name, feed.Url, newUrl)
+	fmt.Println("The feed will be fetched from the new URL on the next aggregation")
+
+	return nil
+}
+
+func handleFeedDelete(s *state, cmd command, user database.User) error {
+	flags := newFlagSet(cmd.name)
+	yes := flags.Bool("yes", false, "do not ask for confirmation")
+
+	args, err := parseFlags(flags, cmd.args)
+	if err != nil {
+		return fmt.Errorf("%v\n%v", err, feedUsage)
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("the feed delete command requires a feed URL.\n%v", feedUsage)
+	}
+
+	// Administrators may delete feeds of other users, e.g. abandoned ones.
+	feed, err := findFeed(s, args[0])
+	if err != nil {
+		return err
+	}
+	if feed.UserID != user.ID && requireAdmin(user) != nil {
+		return fmt.Errorf("%w: %v", ErrNotFeedOwner, feed.Name)
+	}
+
+	followerCount, err := s.database.CountFeedFollowers(context.Background(), feed.ID)
+	if err != nil {
+		return fmt.Errorf("failed to count feed followers: %v", err)
+	}
+
+	fmt.Printf("Deleting the feed %v also deletes all of its posts\n", feed.Name)
+	if followerCount > 0 {
+		fmt.Printf("Warning: the feed is followed by %v user(s), they will stop receiving it\n", followerCount)
+	}
+
+	if !*yes {
+		confirmed, err := confirm("This cannot be undone.")
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			fmt.Println("Feed deletion cancelled")
+			return nil
+		}
+	}
+
+	if err := s.database.DeleteFeed(context.Background(), feed.ID); err != nil {
+		return fmt.Errorf("failed to delete feed: %v", err)
+	}
+
+	fmt.Printf("Feed deleted: %v\n", feed.Name)
+
+	return nil
+}
+
+func handleFeedTransfer(s *state, cmd command, user database.User) error {
+	if len(cmd.args) < 2 {
+		return fmt.Errorf("the feed transfer command requires a feed URL and a user name.\n%v", feedUsage)
+	}
+
+	feed, err := findOwnedFeed(s, cmd.args[0], user)
+	if err != nil {
+		return err
+	}
+
+	newOwner, err := s.database.FindUserByeName(context.Background(), cmd.args[1])
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: %v", ErrUserNotFound, cmd.args[1])
+		}
+
+		return fmt.Errorf("failed to find user: %v", err)
+	}
+
+	err = s.database.TransferFeed(context.Background(), database.TransferFeedParams{
+		UserID: newOwner.ID,
+		ID:     feed.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to transfer feed: %v", err)
+	}
+
+	fmt.Printf("Feed %v transferred to %v\n", feed.Name, newOwner.Name)
+
+	return nil
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/database"
+	"github.com/google/uuid"
+)
+
+var ErrFilterNotFound = errors.New("filter not found")
+
+var filterKinds = []string{"keyword", "regex", "author", "category"}
+
+const filterUsage = `Usage:
+  gator filter add <keyword|regex|author|category> <pattern> [--feed feed_url]
+  gator filter list
+  gator filter remove <filter_id>`
+
+func handleFilter(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return fmt.Errorf("the filter command requires a sub command.\n%v", filterUsage)
+	}
+
+	subCmd := command{
+		name: cmd.name + " " + cmd.args[0],
+		args: cmd.args[1:],
+	}
+
+	switch cmd.args[0] {
+	case "add":
+		return handleFilterAdd(s, subCmd, user)
+	case "list":
+		return handleFilterList(s, subCmd, user)
+	case "remove":
+		return handleFilterRemove(s, subCmd, user)
+	}
+
+	return fmt.Errorf("unknown filter sub command: %v\n%v", cmd.args[0], filterUsage)
+}
+
+func handleFilterAdd(s *state, cmd command, user database.User) error {
+	flags := newFlagSet(cmd.name)
+	feedArg := flags.String("feed", "", "only apply the filter to the feed with this URL")
+
+	args, err := parseFlags(flags, cmd.args)
+	if err != nil {
+		return fmt.Errorf("%v\n%v", err, filterUsage)
+	}
+	if len(args) < 2 {
+		return fmt.Errorf("the filter add command requires a kind and a pattern.\n%v", filterUsage)
+	}
+
+	kind := args[0]
+	pattern := strings.Join(args[1:], " ")
+
+	if !slices.Contains(filterKinds, kind) {
+		return fmt.Errorf("invalid filter kind %q, valid kinds are: %v", kind, strings.Join(filterKinds, ", "))
+	}
+
+	if kind == "regex" {
+		// Patterns are evaluated by PostgreSQL, so let it validate them
+		if _, err := s.database.CheckRegexPattern(context.Background(), pattern); err != nil {
+			return fmt.Errorf("invalid regular expression %q: %v", pattern, err)
+		}
+	}
+
+	feedID := uuid.NullUUID{}
+	feedName := "all feeds"
+	if *feedArg != "" {
+		feed, err := s.database.FindFeedByUrl(context.Background(), *feedArg)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("feed not found: %v", *feedArg)
+			}
+
+			return fmt.Errorf("failed to get feed by URL: %v", err)
+		}
+
+		feedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+		feedName = feed.Name
+	}
+
+	filter, err := s.database.CreatePostFilter(context.Background(), database.CreatePostFilterParams{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		FeedID:    feedID,
+		Kind:      kind,
+		Pattern:   pattern,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create filter: %v", err)
+	}
+
+	fmt.Printf("Filter successfully added\n")
+	fmt.Printf("- Id:      %v\n", filter.ID)
+	fmt.Printf("- Kind:    %v\n", filter.Kind)
+	fmt.Printf("- Pattern: %v\n", filter.Pattern)
+	fmt.Printf("- Applies: %v\n", feedName)
+
+	return nil
+}
+
+func handleFilterList(s *state, cmd command, user database.User) error {
+	filters, err := s.database.GetPostFiltersForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get filters: %v", err)
+	}
+
+	if len(filters) == 0 {
+		fmt.Println("No filters found")
+		return nil
+	}
+
+	fmt.Printf("Current filters\n")
+	fmt.Printf("--------------------------------\n")
+	for _, filter := range filters {
+		feedName := "all feeds"
+		if filter.FeedName.Valid {
+			feedName = filter.FeedName.String
+		}
+
+		fmt.Printf("- Id:      %v\n", filter.ID)
+		fmt.Printf("- Kind:    %v\n", filter.Kind)
+		fmt.Printf("- Pattern: %v\n", filter.Pattern)
+		fmt.Printf("- Applies: %v\n", feedName)
+		fmt.Printf("--------------------------------\n")
+	}
+
+	return nil
+}
+
+func handleFilterRemove(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return fmt.Errorf("the filter remove command requires a filter ID.\n%v", filterUsage)
+	}
+
+	filterID, err := uuid.Parse(cmd.args[0])
+	if err != nil {
+		return fmt.Errorf("invalid filter ID %q: %v", cmd.args[0], err)
+	}
+
+	deletedCount, err := s.database.DeletePostFilter(context.Background(), database.DeletePostFilterParams{
+		ID:     filterID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove filter: %v", err)
+	}
+	if deletedCount == 0 {
+		return fmt.Errorf("%w: %v", ErrFilterNotFound, filterID)
+	}
+
+	fmt.Printf("Filter removed: %v\n", filterID)
+
+	return nil
+}
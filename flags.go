@@ -0,0 +1,35 @@
+package main
+
+import (
+	"flag"
+	"io"
+)
+
+// newFlagSet creates a flag set for a sub command which reports errors to the
+// caller instead of printing them or exiting.
+func newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	return flags
+}
+
+// parseFlags parses flags which may appear before, after or in between the
+// positional arguments (e.g. "gator search golang --limit 5 generics") and
+// returns the positional arguments in order.
+func parseFlags(flags *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+
+		args = flags.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
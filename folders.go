@@ -0,0 +1,300 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/database"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var ErrFolderNotFound = errors.New("folder not found")
+var ErrFolderExists = errors.New("folder already exists")
+var ErrFeedNotFollowed = errors.New("feed is not followed")
+
+const folderUsage = `Usage:
+  gator folder create <name>
+  gator folder add <name> <feed_url>
+  gator folder remove <name> <feed_url>
+  gator folder delete <name>
+  gator folder list`
+
+func findFolder(s *state, userID uuid.UUID, name string) (database.Folder, error) {
+	folder, err := s.database.FindFolderByName(context.Background(), database.FindFolderByNameParams{
+		UserID: userID,
+		Name:   name,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return database.Folder{}, fmt.Errorf("%w: %v", ErrFolderNotFound, name)
+		}
+
+		return database.Folder{}, fmt.Errorf("failed to find folder: %v", err)
+	}
+
+	return folder, nil
+}
+
+// findOrCreateFolder returns the folder with the given name, creating it
+// first when the user does not have one yet.
+func findOrCreateFolder(s *state, userID uuid.UUID, name string) (database.Folder, error) {
+	folder, err := findFolder(s, userID, name)
+	if err == nil || !errors.Is(err, ErrFolderNotFound) {
+		return folder, err
+	}
+
+	folder, err = s.database.CreateFolder(context.Background(), database.CreateFolderParams{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Name:      name,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return database.Folder{}, fmt.Errorf("failed to create folder: %v", err)
+	}
+
+	return folder, nil
+}
+
+func handleFolder(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return fmt.Errorf("the folder command requires a sub command.\n%v", folderUsage)
+	}
+
+	subCmd := command{
+		name: cmd.name + " " + cmd.args[0],
+		args: cmd.args[1:],
+	}
+
+	switch cmd.args[0] {
+	case "create":
+		return handleFolderCreate(s, subCmd, user)
+	case "add":
+		return handleFolderAdd(s, subCmd, user)
+	case "remove":
+		return handleFolderRemove(s, subCmd, user)
+	case "delete":
+		return handleFolderDelete(s, subCmd, user)
+	case "list":
+		return handleFolderList(s, subCmd, user)
+	}
+
+	return fmt.Errorf("unknown folder sub command: %v\n%v", cmd.args[0], folderUsage)
+}
+
+func handleFolderCreate(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return fmt.Errorf("the folder create command requires a name.\n%v", folderUsage)
+	}
+
+	name := cmd.args[0]
+
+	folder, err := s.database.CreateFolder(context.Background(), database.CreateFolderParams{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		Name:      name,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		var pqErr *pq.Error
+
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return fmt.Errorf("%w: %v", ErrFolderExists, name)
+		}
+
+		return fmt.Errorf("failed to create folder: %v", err)
+	}
+
+	fmt.Printf("Folder created: %v\n", folder.Name)
+
+	return nil
+}
+
+// findFollowedFeed looks up the feed by URL among the feeds the user follows,
+// folders only ever contain followed feeds.
+func findFollowedFeed(s *state, userID uuid.UUID, feedUrl string) (database.Feed, error) {
+	feed, err := s.database.FindFollowedFeedByUrl(context.Background(), database.FindFollowedFeedByUrlParams{
+		UserID: userID,
+		Url:    feedUrl,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return database.Feed{}, fmt.Errorf("%w: %v. Use: gator follow %v", ErrFeedNotFollowed, feedUrl, feedUrl)
+		}
+
+		return database.Feed{}, fmt.Errorf("failed to find followed feed: %v", err)
+	}
+
+	return feed, nil
+}
+
+func handleFolderAdd(s *state, cmd command, user database.User) error {
+	if len(cmd.args) < 2 {
+		return fmt.Errorf("the folder add command requires a folder name and a feed URL.\n%v", folderUsage)
+	}
+
+	folder, err := findFolder(s, user.ID, cmd.args[0])
+	if err != nil {
+		return err
+	}
+
+	feed, err := findFollowedFeed(s, user.ID, cmd.args[1])
+	if err != nil {
+		return err
+	}
+
+	err = s.database.AddFeedToFolder(context.Background(), database.AddFeedToFolderParams{
+		FolderID:  folder.ID,
+		FeedID:    feed.ID,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add feed to folder: %v", err)
+	}
+
+	fmt.Printf("Feed %v added to folder %v\n", feed.Name, folder.Name)
+
+	return nil
+}
+
+func handleFolderRemove(s *state, cmd command, user database.User) error {
+	if len(cmd.args) < 2 {
+		return fmt.Errorf("the folder remove command requires a folder name and a feed URL.\n%v", folderUsage)
+	}
+
+	folder, err := findFolder(s, user.ID, cmd.args[0])
+	if err != nil {
+		return err
+	}
+
+	feed, err := s.database.FindFeedByUrl(context.Background(), cmd.args[1])
+	if err != nil {
+		return fmt.Errorf("failed to find feed by URL: %v", err)
+	}
+
+	removedCount, err := s.database.RemoveFeedFromFolder(context.Background(), database.RemoveFeedFromFolderParams{
+		FolderID: folder.ID,
+		FeedID:   feed.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove feed from folder: %v", err)
+	}
+	if removedCount == 0 {
+		return fmt.Errorf("feed %v is not in folder %v", feed.Name, folder.Name)
+	}
+
+	fmt.Printf("Feed %v removed from folder %v\n", feed.Name, folder.Name)
+
+	return nil
+}
+
+func handleFolderDelete(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return fmt.Errorf("the folder delete command requires a name.\n%v", folderUsage)
+	}
+
+	name := cmd.args[0]
+
+	deletedCount, err := s.database.DeleteFolder(context.Background(), database.DeleteFolderParams{
+		UserID: user.ID,
+		Name:   name,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete folder: %v", err)
+	}
+	if deletedCount == 0 {
+		return fmt.Errorf("%w: %v", ErrFolderNotFound, name)
+	}
+
+	fmt.Printf("Folder deleted: %v\n", name)
+
+	return nil
+}
+
+func handleFolderList(s *state, cmd command, user database.User) error {
+	folders, err := s.database.GetFoldersForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get folders: %v", err)
+	}
+
+	if len(folders) == 0 {
+		fmt.Println("No folders found")
+		return nil
+	}
+
+	fmt.Printf("Folders\n")
+	fmt.Printf("--------------------------------\n")
+	for _, folder := range folders {
+		fmt.Printf("- Name:  %v\n", folder.Name)
+		fmt.Printf("- Feeds: %v\n", folder.FeedCount)
+		fmt.Printf("--------------------------------\n")
+	}
+
+	return nil
+}
+
+// printFeedFollowsByFolder lists the followed feeds grouped by the folders
+// they are in. A feed may be listed under several folders, feeds without a
+// folder are listed last. Without any folders the list stays flat.
+func printFeedFollowsByFolder(s *state, user database.User, feedFollows []database.GetFeedFollowsForUserRow) error {
+	folderFeeds, err := s.database.GetFolderFeedsForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get folders for user: %v", err)
+	}
+
+	printFeedFollow := func(indent string, feedFollow database.GetFeedFollowsForUserRow) {
+		notify := ""
+		if feedFollow.Notify {
+			notify = " 🔔"
+		}
+		fmt.Printf("%v- %v (%v unread)%v\n", indent, feedFollow.FeedName, feedFollow.UnreadCount, notify)
+	}
+
+	if len(folderFeeds) == 0 {
+		for _, feedFollow := range feedFollows {
+			printFeedFollow("", feedFollow)
+		}
+		return nil
+	}
+
+	folderNames := []string{}
+	feedsByFolder := map[string]map[uuid.UUID]bool{}
+	filed := map[uuid.UUID]bool{}
+	for _, folderFeed := range folderFeeds {
+		if _, ok := feedsByFolder[folderFeed.FolderName]; !ok {
+			folderNames = append(folderNames, folderFeed.FolderName)
+			feedsByFolder[folderFeed.FolderName] = map[uuid.UUID]bool{}
+		}
+		feedsByFolder[folderFeed.FolderName][folderFeed.FeedID] = true
+		filed[folderFeed.FeedID] = true
+	}
+
+	for _, folderName := range folderNames {
+		fmt.Printf("%v/\n", folderName)
+		for _, feedFollow := range feedFollows {
+			if feedsByFolder[folderName][feedFollow.FeedID] {
+				printFeedFollow("  ", feedFollow)
+			}
+		}
+	}
+
+	unfiled := []database.GetFeedFollowsForUserRow{}
+	for _, feedFollow := range feedFollows {
+		if !filed[feedFollow.FeedID] {
+			unfiled = append(unfiled, feedFollow)
+		}
+	}
+
+	if len(unfiled) > 0 {
+		fmt.Printf("Unfiled/\n")
+		for _, feedFollow := range unfiled {
+			printFeedFollow("  ", feedFollow)
+		}
+	}
+
+	return nil
+}
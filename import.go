@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/database"
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/opml"
+	"github.com/google/uuid"
+)
+
+var ErrFeedFollowExists = errors.New("feed is already followed")
+
+const importUsage = `Usage:
+  gator import opml <file>`
+
+// feedImport is the outcome of importing a single feed.
+type feedImport int
+
+const (
+	feedFollowed feedImport = iota
+	feedCreated
+	feedAlreadyFollowed
+)
+
+type importResult struct {
+	created   int
+	followed  int
+	duplicate int
+	failed    int
+}
+
+func handleImport(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return fmt.Errorf("the import command requires a format.\n%v", importUsage)
+	}
+
+	subCmd := command{
+		name: cmd.name + " " + cmd.args[0],
+		args: cmd.args[1:],
+	}
+
+	switch cmd.args[0] {
+	case "opml":
+		return handleImportOPML(s, subCmd, user)
+	}
+
+	return fmt.Errorf("unknown import format: %v\n%v", cmd.args[0], importUsage)
+}
+
+// handleImportOPML creates and follows every feed of an OPML file. The whole
+// import runs in a single transaction, every feed gets its own savepoint so a
+// single broken entry is reported instead of aborting the import.
+func handleImportOPML(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return fmt.Errorf("the import opml command requires a file.\n%v", importUsage)
+	}
+
+	file, err := os.Open(cmd.args[0])
+	if err != nil {
+		return fmt.Errorf("failed to open OPML file: %v", err)
+	}
+	defer file.Close()
+
+	doc, err := opml.Parse(file)
+	if err != nil {
+		return fmt.Errorf("failed to read OPML file: %v", err)
+	}
+
+	feeds := doc.Feeds()
+	if len(feeds) == 0 {
+		fmt.Println("No feeds found in the OPML file")
+		return nil
+	}
+
+	tx, err := s.db.BeginTx(context.Background(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	txState := &state{
+		config:   s.config,
+		database: s.database.WithTx(tx),
+		db:       s.db,
+	}
+
+	result := importResult{}
+	for _, feed := range feeds {
+		if _, err := tx.ExecContext(context.Background(), "SAVEPOINT import_feed"); err != nil {
+			return fmt.Errorf("failed to create savepoint: %v", err)
+		}
+
+		outcome, err := importOPMLFeed(txState, user, feed)
+		if err != nil {
+			if _, rollbackErr := tx.ExecContext(context.Background(), "ROLLBACK TO SAVEPOINT import_feed"); rollbackErr != nil {
+				return fmt.Errorf("failed to roll back to savepoint: %v", rollbackErr)
+			}
+
+			result.failed++
+			fmt.Printf("! Failed: %v (%v): %v\n", feed.Title, feed.XMLURL, err)
+			continue
+		}
+
+		if _, err := tx.ExecContext(context.Background(), "RELEASE SAVEPOINT import_feed"); err != nil {
+			return fmt.Errorf("failed to release savepoint: %v", err)
+		}
+
+		if outcome == feedAlreadyFollowed {
+			result.duplicate++
+			fmt.Printf("= Already following: %v\n", feed.Title)
+			continue
+		}
+
+		if outcome == feedCreated {
+			result.created++
+		}
+		result.followed++
+		fmt.Printf("+ Followed: %v\n", feed.Title)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit the import: %v", err)
+	}
+
+	fmt.Printf("Import finished\n")
+	fmt.Printf("- Feeds in file:     %v\n", len(feeds))
+	fmt.Printf("- Feeds created:     %v\n", result.created)
+	fmt.Printf("- Feeds followed:    %v\n", result.followed)
+	fmt.Printf("- Already following: %v\n", result.duplicate)
+	fmt.Printf("- Failed:            %v\n", result.failed)
+
+	return nil
+}
+
+// importOPMLFeed creates the feed unless it already exists, follows it and
+// puts it into the folder it was nested in. Feeds which are already followed,
+// e.g. because they are listed in several folders, are still put into the
+// folder.
+func importOPMLFeed(s *state, user database.User, feed opml.Feed) (feedImport, error) {
+	if feed.XMLURL == "" {
+		return feedFollowed, fmt.Errorf("the outline has no feed URL")
+	}
+
+	name := feed.Title
+	if name == "" {
+		name = feed.XMLURL
+	}
+
+	outcome := feedFollowed
+	existingFeed, err := s.database.FindFeedByUrl(context.Background(), feed.XMLURL)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return feedFollowed, fmt.Errorf("failed to find feed by URL: %v", err)
+		}
+
+		existingFeed, err = s.database.CreateFeed(context.Background(), database.CreateFeedParams{
+			ID:     uuid.New(),
+			UserID: user.ID,
+			Url:    feed.XMLURL,
+			Name:   name,
+		})
+		if err != nil {
+			return feedFollowed, fmt.Errorf("failed to create feed: %v", err)
+		}
+		outcome = feedCreated
+
+		if feed.HTMLURL != "" {
+			err = s.database.SetFeedSiteUrl(context.Background(), database.SetFeedSiteUrlParams{
+				SiteUrl: feed.HTMLURL,
+				ID:      existingFeed.ID,
+			})
+			if err != nil {
+				return feedFollowed, fmt.Errorf("failed to set feed site URL: %v", err)
+			}
+		}
+	}
+
+	// A failed insert would abort the transaction, so conflicts are skipped
+	// instead of reported.
+	followCount, err := s.database.FollowFeedIfNotFollowed(context.Background(), database.FollowFeedIfNotFollowedParams{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		FeedID:    existingFeed.ID,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return feedFollowed, fmt.Errorf("failed to follow the feed: %v", err)
+	}
+	if followCount == 0 {
+		outcome = feedAlreadyFollowed
+	}
+
+	if feed.Folder != "" {
+		folder, err := findOrCreateFolder(s, user.ID, feed.Folder)
+		if err != nil {
+			return feedFollowed, err
+		}
+
+		err = s.database.AddFeedToFolder(context.Background(), database.AddFeedToFolderParams{
+			FolderID:  folder.ID,
+			FeedID:    existingFeed.ID,
+			CreatedAt: time.Now(),
+		})
+		if err != nil {
+			return feedFollowed, fmt.Errorf("failed to add feed to folder: %v", err)
+		}
+	}
+
+	return outcome, nil
+}
@@ -12,6 +12,23 @@ const configFilename = ".gatorconfig.json"
 type Config struct {
 	DBUrl string `json:"db_url"`
 	CurrentUserName *string `json:"current_user_name"`
+	SessionToken *string `json:"session_token,omitempty"`
+	SMTP *SMTPConfig `json:"smtp,omitempty"`
+	NotifyCommand []string `json:"notify_command,omitempty"`
+	LogFormat string `json:"log_format,omitempty"`
+	LogLevel string `json:"log_level,omitempty"`
+}
+
+// SMTPConfig is the mail server digests are sent through. TLS is "starttls"
+// (the default), "tls" for implicit TLS (usually port 465) or "none" for local
+// servers.
+type SMTPConfig struct {
+	Host string `json:"host"`
+	Port int `json:"port"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	From string `json:"from"`
+	TLS string `json:"tls,omitempty"`
 }
 
 func getConfigPath() (string, error) {
@@ -34,7 +51,8 @@ func writeConfig(config *Config) error {
 		return err
 	}
 
-	if err := os.WriteFile(configPath, jsonData, 0666); err != nil {
+	// The config holds the session token, keep it private to the user.
+	if err := os.WriteFile(configPath, jsonData, 0600); err != nil {
 		return err
 	}
 
@@ -72,3 +90,18 @@ func (c *Config) SetUser(currentUserName string) error {
 
 	return writeConfig(c)
 }
+
+func (c *Config) SetSession(currentUserName string, sessionToken string) error {
+	c.CurrentUserName = &currentUserName
+	c.SessionToken = &sessionToken
+
+	return writeConfig(c)
+}
+
+func (c *Config) ClearSession() error {
+	currentUserName := ""
+	c.CurrentUserName = &currentUserName
+	c.SessionToken = nil
+
+	return writeConfig(c)
+}
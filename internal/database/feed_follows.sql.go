@@ -17,11 +17,11 @@ WITH inserted_feed_follows AS (
   INSERT INTO feed_follows (id,
   user_id, feed_id, created_at)
   VALUES ($1, $2, $3, $4)
-  RETURNING id, user_id, feed_id, created_at
+  RETURNING id, user_id, feed_id, created_at, notify
 )
 
 SELECT 
-  inserted_feed_follows.id, inserted_feed_follows.user_id, inserted_feed_follows.feed_id, inserted_feed_follows.created_at,
+  inserted_feed_follows.id, inserted_feed_follows.user_id, inserted_feed_follows.feed_id, inserted_feed_follows.created_at, inserted_feed_follows.notify,
   feeds.name as feed_name,
   users.name as user_name
 FROM inserted_feed_follows
@@ -43,6 +43,7 @@ type CreateFeedFollowRow struct {
 	UserID    uuid.UUID
 	FeedID    uuid.UUID
 	CreatedAt time.Time
+	Notify    bool
 	FeedName  string
 	UserName  string
 }
@@ -60,6 +61,7 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 		&i.UserID,
 		&i.FeedID,
 		&i.CreatedAt,
+		&i.Notify,
 		&i.FeedName,
 		&i.UserName,
 	)
@@ -82,11 +84,73 @@ func (q *Queries) DeleteFeedFollow(ctx context.Context, arg DeleteFeedFollowPara
 	return err
 }
 
+const findFollowedFeedByUrl = `-- name: FindFollowedFeedByUrl :one
+SELECT feeds.id, feeds.user_id, feeds.url, feeds.name, feeds.created_at, feeds.last_fetched_at, feeds.site_url
+FROM feeds
+INNER JOIN feed_follows ON feed_follows.feed_id = feeds.id
+WHERE feed_follows.user_id = $1 AND feeds.url = $2
+`
+
+type FindFollowedFeedByUrlParams struct {
+	UserID uuid.UUID
+	Url    string
+}
+
+func (q *Queries) FindFollowedFeedByUrl(ctx context.Context, arg FindFollowedFeedByUrlParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, findFollowedFeedByUrl, arg.UserID, arg.Url)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Url,
+		&i.Name,
+		&i.CreatedAt,
+		&i.LastFetchedAt,
+		&i.SiteUrl,
+	)
+	return i, err
+}
+
+const followFeedIfNotFollowed = `-- name: FollowFeedIfNotFollowed :execrows
+INSERT INTO feed_follows (id, user_id, feed_id, created_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id, feed_id) DO NOTHING
+`
+
+type FollowFeedIfNotFollowedParams struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	FeedID    uuid.UUID
+	CreatedAt time.Time
+}
+
+func (q *Queries) FollowFeedIfNotFollowed(ctx context.Context, arg FollowFeedIfNotFollowedParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, followFeedIfNotFollowed,
+		arg.ID,
+		arg.UserID,
+		arg.FeedID,
+		arg.CreatedAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const getFeedFollowsForUser = `-- name: GetFeedFollowsForUser :many
 SELECT 
   feed_follows.id AS feed_follow_id,
+  feeds.id AS feed_id,
   feeds.name as feed_name,
-  feeds.url as feed_url
+  feeds.url as feed_url,
+  feeds.site_url as feed_site_url,
+  feed_follows.notify,
+  (
+    SELECT COUNT(*)
+    FROM posts
+    LEFT JOIN post_reads ON post_reads.post_id = posts.id AND post_reads.user_id = feed_follows.user_id
+    WHERE posts.feed_id = feeds.id AND post_reads.post_id IS NULL
+  ) AS unread_count
 FROM feed_follows
 INNER JOIN feeds ON feed_follows.feed_id = feeds.id
 WHERE feed_follows.user_id = $1 
@@ -95,8 +159,12 @@ ORDER BY feed_follows.created_at ASC
 
 type GetFeedFollowsForUserRow struct {
 	FeedFollowID uuid.UUID
+	FeedID       uuid.UUID
 	FeedName     string
 	FeedUrl      string
+	FeedSiteUrl  string
+	Notify       bool
+	UnreadCount  int64
 }
 
 func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) ([]GetFeedFollowsForUserRow, error) {
@@ -108,7 +176,15 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) (
 	var items []GetFeedFollowsForUserRow
 	for rows.Next() {
 		var i GetFeedFollowsForUserRow
-		if err := rows.Scan(&i.FeedFollowID, &i.FeedName, &i.FeedUrl); err != nil {
+		if err := rows.Scan(
+			&i.FeedFollowID,
+			&i.FeedID,
+			&i.FeedName,
+			&i.FeedUrl,
+			&i.FeedSiteUrl,
+			&i.Notify,
+			&i.UnreadCount,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -121,3 +197,54 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) (
 	}
 	return items, nil
 }
+
+const getNotifyFollowersForPost = `-- name: GetNotifyFollowersForPost :many
+SELECT users.name
+FROM feed_follows
+INNER JOIN users ON users.id = feed_follows.user_id
+INNER JOIN posts ON posts.feed_id = feed_follows.feed_id
+WHERE posts.id = $1
+  AND feed_follows.notify
+  AND NOT post_is_filtered(feed_follows.user_id, posts.id)
+ORDER BY users.name ASC
+`
+
+func (q *Queries) GetNotifyFollowersForPost(ctx context.Context, id uuid.UUID) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, getNotifyFollowersForPost, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		items = append(items, name)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setFeedFollowNotify = `-- name: SetFeedFollowNotify :exec
+UPDATE feed_follows
+SET notify = $1
+WHERE user_id = $2 AND feed_id = $3
+`
+
+type SetFeedFollowNotifyParams struct {
+	Notify bool
+	UserID uuid.UUID
+	FeedID uuid.UUID
+}
+
+func (q *Queries) SetFeedFollowNotify(ctx context.Context, arg SetFeedFollowNotifyParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedFollowNotify, arg.Notify, arg.UserID, arg.FeedID)
+	return err
+}
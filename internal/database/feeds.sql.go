@@ -12,10 +12,21 @@ import (
 	"github.com/google/uuid"
 )
 
+const countFeedFollowers = `-- name: CountFeedFollowers :one
+SELECT COUNT(*) FROM feed_follows WHERE feed_id = $1
+`
+
+func (q *Queries) CountFeedFollowers(ctx context.Context, feedID uuid.UUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countFeedFollowers, feedID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createFeed = `-- name: CreateFeed :one
 INSERT INTO feeds (id, user_id, url, name)
 VALUES ($1, $2, $3, $4)
-RETURNING id, user_id, url, name, created_at, last_fetched_at
+RETURNING id, user_id, url, name, created_at, last_fetched_at, site_url
 `
 
 type CreateFeedParams struct {
@@ -40,12 +51,22 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		&i.Name,
 		&i.CreatedAt,
 		&i.LastFetchedAt,
+		&i.SiteUrl,
 	)
 	return i, err
 }
 
+const deleteFeed = `-- name: DeleteFeed :exec
+DELETE FROM feeds WHERE id = $1
+`
+
+func (q *Queries) DeleteFeed(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteFeed, id)
+	return err
+}
+
 const findFeedByUrl = `-- name: FindFeedByUrl :one
-SELECT id, user_id, url, name, created_at, last_fetched_at FROM feeds WHERE url = $1 LIMIT 1
+SELECT id, user_id, url, name, created_at, last_fetched_at, site_url FROM feeds WHERE url = $1 LIMIT 1
 `
 
 func (q *Queries) FindFeedByUrl(ctx context.Context, url string) (Feed, error) {
@@ -58,6 +79,7 @@ func (q *Queries) FindFeedByUrl(ctx context.Context, url string) (Feed, error) {
 		&i.Name,
 		&i.CreatedAt,
 		&i.LastFetchedAt,
+		&i.SiteUrl,
 	)
 	return i, err
 }
@@ -104,7 +126,7 @@ func (q *Queries) GetFeeds(ctx context.Context) ([]GetFeedsRow, error) {
 }
 
 const getNextFeedToFetch = `-- name: GetNextFeedToFetch :one
-SELECT id, user_id, url, name, created_at, last_fetched_at 
+SELECT id, user_id, url, name, created_at, last_fetched_at, site_url 
 FROM feeds 
 WHERE last_fetched_at IS NULL
 ORDER BY created_at ASC, last_fetched_at IS NULL DESC
@@ -121,6 +143,7 @@ func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
 		&i.Name,
 		&i.CreatedAt,
 		&i.LastFetchedAt,
+		&i.SiteUrl,
 	)
 	return i, err
 }
@@ -138,3 +161,59 @@ func (q *Queries) MarkFeedFetched(ctx context.Context, arg MarkFeedFetchedParams
 	_, err := q.db.ExecContext(ctx, markFeedFetched, arg.LastFetchedAt, arg.ID)
 	return err
 }
+
+const renameFeed = `-- name: RenameFeed :exec
+UPDATE feeds SET name = $1 WHERE id = $2
+`
+
+type RenameFeedParams struct {
+	Name string
+	ID   uuid.UUID
+}
+
+func (q *Queries) RenameFeed(ctx context.Context, arg RenameFeedParams) error {
+	_, err := q.db.ExecContext(ctx, renameFeed, arg.Name, arg.ID)
+	return err
+}
+
+const setFeedSiteUrl = `-- name: SetFeedSiteUrl :exec
+UPDATE feeds SET site_url = $1 WHERE id = $2
+`
+
+type SetFeedSiteUrlParams struct {
+	SiteUrl string
+	ID      uuid.UUID
+}
+
+func (q *Queries) SetFeedSiteUrl(ctx context.Context, arg SetFeedSiteUrlParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedSiteUrl, arg.SiteUrl, arg.ID)
+	return err
+}
+
+const setFeedUrl = `-- name: SetFeedUrl :exec
+UPDATE feeds SET url = $1, last_fetched_at = NULL WHERE id = $2
+`
+
+type SetFeedUrlParams struct {
+	Url string
+	ID  uuid.UUID
+}
+
+func (q *Queries) SetFeedUrl(ctx context.Context, arg SetFeedUrlParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedUrl, arg.Url, arg.ID)
+	return err
+}
+
+const transferFeed = `-- name: TransferFeed :exec
+UPDATE feeds SET user_id = $1 WHERE id = $2
+`
+
+type TransferFeedParams struct {
+	UserID uuid.UUID
+	ID     uuid.UUID
+}
+
+func (q *Queries) TransferFeed(ctx context.Context, arg TransferFeedParams) error {
+	_, err := q.db.ExecContext(ctx, transferFeed, arg.UserID, arg.ID)
+	return err
+}
@@ -11,6 +11,52 @@ import (
 	"github.com/google/uuid"
 )
 
+type ApiKey struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	KeyHash    string
+	CreatedAt  time.Time
+	Label      string
+	Scope      string
+	LastUsedAt sql.NullTime
+	ExpiresAt  sql.NullTime
+}
+
+type Bookmark struct {
+	UserID          uuid.UUID
+	PostID          uuid.UUID
+	Note            string
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	PostTitle       string
+	PostUrl         string
+	PostDescription string
+	PostAuthor      string
+	PostPublishedAt sql.NullTime
+	FeedName        string
+}
+
+type Digest struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Email     string
+	PostCount int32
+	SentAt    time.Time
+}
+
+type DigestPost struct {
+	DigestID uuid.UUID
+	PostID   uuid.UUID
+}
+
+type DigestSubscription struct {
+	UserID    uuid.UUID
+	Email     string
+	Frequency string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
 type Feed struct {
 	ID            uuid.UUID
 	UserID        uuid.UUID
@@ -18,6 +64,7 @@ type Feed struct {
 	Name          string
 	CreatedAt     time.Time
 	LastFetchedAt sql.NullTime
+	SiteUrl       string
 }
 
 type FeedFollow struct {
@@ -25,22 +72,122 @@ type FeedFollow struct {
 	UserID    uuid.UUID
 	FeedID    uuid.UUID
 	CreatedAt time.Time
+	Notify    bool
+}
+
+type Folder struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Name      string
+	CreatedAt time.Time
+}
+
+type FolderFeed struct {
+	FolderID  uuid.UUID
+	FeedID    uuid.UUID
+	CreatedAt time.Time
 }
 
 type Post struct {
-	ID          uuid.UUID
-	FeedID      uuid.UUID
-	Title       string
-	Url         string
-	Description string
-	PublishedAt sql.NullTime
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	ID           uuid.UUID
+	FeedID       uuid.UUID
+	Title        string
+	Url          string
+	Description  string
+	PublishedAt  sql.NullTime
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Author       string
+	Content      string
+	SearchVector interface{}
+	Categories   []string
 }
 
-type User struct {
+type PostFilter struct {
 	ID        uuid.UUID
+	UserID    uuid.UUID
+	FeedID    uuid.NullUUID
+	Kind      string
+	Pattern   string
+	CreatedAt time.Time
+}
+
+type PostRead struct {
+	UserID uuid.UUID
+	PostID uuid.UUID
+	ReadAt time.Time
+}
+
+type PostTag struct {
+	UserID    uuid.UUID
+	PostID    uuid.UUID
+	Tag       string
+	CreatedAt time.Time
+}
+
+type RiverToken struct {
+	UserID    uuid.UUID
+	TokenHash string
+	CreatedAt time.Time
+}
+
+type Rule struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	Name       string
+	Expression string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+type SavedSearch struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
 	Name      string
+	Query     string
+	Notify    bool
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
+
+type Session struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	TokenHash  string
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	ExpiresAt  sql.NullTime
+}
+
+type User struct {
+	ID           uuid.UUID
+	Name         string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	PasswordHash string
+	Role         string
+}
+
+type Webhook struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Name      string
+	Url       string
+	Format    string
+	Secret    string
+	FeedID    uuid.NullUUID
+	Tag       sql.NullString
+	CreatedAt time.Time
+}
+
+type WebhookDelivery struct {
+	ID         uuid.UUID
+	WebhookID  uuid.UUID
+	PostID     uuid.NullUUID
+	Event      string
+	Attempts   int32
+	StatusCode sql.NullInt32
+	Error      string
+	Succeeded  bool
+	CreatedAt  time.Time
+}
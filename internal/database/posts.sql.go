@@ -11,12 +11,13 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createPost = `-- name: CreatePost :one
-INSERT INTO posts (id, feed_id, title, url, description, published_at, created_at, updated_at)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-RETURNING id, feed_id, title, url, description, published_at, created_at, updated_at
+INSERT INTO posts (id, feed_id, title, url, description, published_at, created_at, updated_at, author, content, categories)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+RETURNING id, feed_id, title, url, description, published_at, created_at, updated_at, author, content, search_vector, categories
 `
 
 type CreatePostParams struct {
@@ -28,6 +29,9 @@ type CreatePostParams struct {
 	PublishedAt sql.NullTime
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
+	Author      string
+	Content     string
+	Categories  []string
 }
 
 func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, error) {
@@ -40,6 +44,9 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		arg.PublishedAt,
 		arg.CreatedAt,
 		arg.UpdatedAt,
+		arg.Author,
+		arg.Content,
+		pq.Array(arg.Categories),
 	)
 	var i Post
 	err := row.Scan(
@@ -51,33 +58,357 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		&i.PublishedAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Author,
+		&i.Content,
+		&i.SearchVector,
+		pq.Array(&i.Categories),
 	)
 	return i, err
 }
 
-const getPostForUser = `-- name: GetPostForUser :many
-SELECT posts.id, posts.feed_id, posts.title, posts.url, posts.description, posts.published_at, posts.created_at, posts.updated_at
+const findPostForUser = `-- name: FindPostForUser :one
+SELECT
+  posts.id,
+  posts.feed_id,
+  posts.title,
+  posts.url,
+  posts.description,
+  posts.published_at,
+  posts.created_at,
+  posts.updated_at,
+  posts.author,
+  posts.content,
+  posts.categories,
+  feeds.name AS feed_name,
+  feeds.url AS feed_url
 FROM posts
 INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
-WHERE feed_follows.user_id = $1
-ORDER BY posts.created_at DESC
-LIMIT $2
+INNER JOIN feeds ON feeds.id = posts.feed_id
+WHERE posts.id = $1 AND feed_follows.user_id = $2
+LIMIT 1
 `
 
-type GetPostForUserParams struct {
+type FindPostForUserParams struct {
+	ID     uuid.UUID
 	UserID uuid.UUID
-	Limit  int32
 }
 
-func (q *Queries) GetPostForUser(ctx context.Context, arg GetPostForUserParams) ([]Post, error) {
-	rows, err := q.db.QueryContext(ctx, getPostForUser, arg.UserID, arg.Limit)
+type FindPostForUserRow struct {
+	ID          uuid.UUID
+	FeedID      uuid.UUID
+	Title       string
+	Url         string
+	Description string
+	PublishedAt sql.NullTime
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Author      string
+	Content     string
+	Categories  []string
+	FeedName    string
+	FeedUrl     string
+}
+
+func (q *Queries) FindPostForUser(ctx context.Context, arg FindPostForUserParams) (FindPostForUserRow, error) {
+	row := q.db.QueryRowContext(ctx, findPostForUser, arg.ID, arg.UserID)
+	var i FindPostForUserRow
+	err := row.Scan(
+		&i.ID,
+		&i.FeedID,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Author,
+		&i.Content,
+		pq.Array(&i.Categories),
+		&i.FeedName,
+		&i.FeedUrl,
+	)
+	return i, err
+}
+
+const getPostsForUser = `-- name: GetPostsForUser :many
+SELECT
+  posts.id,
+  posts.feed_id,
+  posts.title,
+  posts.url,
+  posts.description,
+  posts.published_at,
+  posts.created_at,
+  posts.updated_at,
+  posts.author,
+  posts.content,
+  posts.categories,
+  feeds.name AS feed_name,
+  feeds.url AS feed_url,
+  (post_reads.post_id IS NOT NULL)::boolean AS is_read,
+  (bookmarks.post_id IS NOT NULL)::boolean AS is_starred,
+  post_is_filtered(feed_follows.user_id, posts.id)::boolean AS is_filtered,
+  ARRAY(
+    SELECT post_tags.tag FROM post_tags
+    WHERE post_tags.post_id = posts.id AND post_tags.user_id = feed_follows.user_id
+    ORDER BY post_tags.tag
+  )::text[] AS tags,
+  COALESCE(posts.published_at, posts.created_at) AS sort_key
+FROM posts
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+INNER JOIN feeds ON feeds.id = posts.feed_id
+LEFT JOIN post_reads ON post_reads.post_id = posts.id AND post_reads.user_id = feed_follows.user_id
+LEFT JOIN bookmarks ON bookmarks.post_id = posts.id AND bookmarks.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = $1
+  AND ($2::text IS NULL OR LOWER(feeds.name) = LOWER($2::text) OR feeds.url = $2::text)
+  AND ($3::text IS NULL OR posts.author ILIKE '%' || $3::text || '%')
+  AND (NOT $4::boolean OR post_reads.post_id IS NULL)
+  AND ($5::text IS NULL OR posts.search_vector @@ websearch_to_tsquery('english', $5::text))
+  AND (
+    $6::text IS NULL
+    OR EXISTS (
+      SELECT 1 FROM folder_feeds
+      INNER JOIN folders ON folders.id = folder_feeds.folder_id
+      WHERE folders.user_id = feed_follows.user_id AND LOWER(folders.name) = LOWER($6::text) AND folder_feeds.feed_id = posts.feed_id
+    )
+  )
+  AND (
+    $7::text IS NULL
+    OR EXISTS (
+      SELECT 1 FROM post_tags
+      WHERE post_tags.post_id = posts.id AND post_tags.user_id = feed_follows.user_id AND post_tags.tag = LOWER($7::text)
+    )
+  )
+  AND ($8::boolean OR NOT post_is_filtered(feed_follows.user_id, posts.id))
+  AND ($9::timestamptz IS NULL OR COALESCE(posts.published_at, posts.created_at) >= $9::timestamptz)
+  AND ($10::timestamptz IS NULL OR COALESCE(posts.published_at, posts.created_at) < $10::timestamptz)
+  AND (
+    $11::timestamptz IS NULL
+    OR (COALESCE(posts.published_at, posts.created_at), posts.id) < ($11::timestamptz, $12::uuid)
+  )
+ORDER BY COALESCE(posts.published_at, posts.created_at) DESC, posts.id DESC
+LIMIT $13
+OFFSET $14
+`
+
+type GetPostsForUserParams struct {
+	UserID       uuid.UUID
+	Feed         sql.NullString
+	Author       sql.NullString
+	UnreadOnly   bool
+	Query        sql.NullString
+	Folder       sql.NullString
+	Tag          sql.NullString
+	ShowFiltered bool
+	Since        sql.NullTime
+	Before       sql.NullTime
+	CursorTime   sql.NullTime
+	CursorID     uuid.NullUUID
+	Limit        int32
+	Offset       int32
+}
+
+type GetPostsForUserRow struct {
+	ID          uuid.UUID
+	FeedID      uuid.UUID
+	Title       string
+	Url         string
+	Description string
+	PublishedAt sql.NullTime
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Author      string
+	Content     string
+	Categories  []string
+	FeedName    string
+	FeedUrl     string
+	IsRead      bool
+	IsStarred   bool
+	IsFiltered  bool
+	Tags        []string
+	SortKey     time.Time
+}
+
+func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]GetPostsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsForUser,
+		arg.UserID,
+		arg.Feed,
+		arg.Author,
+		arg.UnreadOnly,
+		arg.Query,
+		arg.Folder,
+		arg.Tag,
+		arg.ShowFiltered,
+		arg.Since,
+		arg.Before,
+		arg.CursorTime,
+		arg.CursorID,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPostsForUserRow
+	for rows.Next() {
+		var i GetPostsForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.FeedID,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Author,
+			&i.Content,
+			pq.Array(&i.Categories),
+			&i.FeedName,
+			&i.FeedUrl,
+			&i.IsRead,
+			&i.IsStarred,
+			&i.IsFiltered,
+			pq.Array(&i.Tags),
+			&i.SortKey,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPostsForUserByFetched = `-- name: GetPostsForUserByFetched :many
+SELECT
+  posts.id,
+  posts.feed_id,
+  posts.title,
+  posts.url,
+  posts.description,
+  posts.published_at,
+  posts.created_at,
+  posts.updated_at,
+  posts.author,
+  posts.content,
+  posts.categories,
+  feeds.name AS feed_name,
+  feeds.url AS feed_url,
+  (post_reads.post_id IS NOT NULL)::boolean AS is_read,
+  (bookmarks.post_id IS NOT NULL)::boolean AS is_starred,
+  post_is_filtered(feed_follows.user_id, posts.id)::boolean AS is_filtered,
+  ARRAY(
+    SELECT post_tags.tag FROM post_tags
+    WHERE post_tags.post_id = posts.id AND post_tags.user_id = feed_follows.user_id
+    ORDER BY post_tags.tag
+  )::text[] AS tags,
+  posts.created_at AS sort_key
+FROM posts
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+INNER JOIN feeds ON feeds.id = posts.feed_id
+LEFT JOIN post_reads ON post_reads.post_id = posts.id AND post_reads.user_id = feed_follows.user_id
+LEFT JOIN bookmarks ON bookmarks.post_id = posts.id AND bookmarks.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = $1
+  AND ($2::text IS NULL OR LOWER(feeds.name) = LOWER($2::text) OR feeds.url = $2::text)
+  AND ($3::text IS NULL OR posts.author ILIKE '%' || $3::text || '%')
+  AND (NOT $4::boolean OR post_reads.post_id IS NULL)
+  AND ($5::text IS NULL OR posts.search_vector @@ websearch_to_tsquery('english', $5::text))
+  AND (
+    $6::text IS NULL
+    OR EXISTS (
+      SELECT 1 FROM folder_feeds
+      INNER JOIN folders ON folders.id = folder_feeds.folder_id
+      WHERE folders.user_id = feed_follows.user_id AND LOWER(folders.name) = LOWER($6::text) AND folder_feeds.feed_id = posts.feed_id
+    )
+  )
+  AND (
+    $7::text IS NULL
+    OR EXISTS (
+      SELECT 1 FROM post_tags
+      WHERE post_tags.post_id = posts.id AND post_tags.user_id = feed_follows.user_id AND post_tags.tag = LOWER($7::text)
+    )
+  )
+  AND ($8::boolean OR NOT post_is_filtered(feed_follows.user_id, posts.id))
+  AND ($9::timestamptz IS NULL OR posts.created_at >= $9::timestamptz)
+  AND ($10::timestamptz IS NULL OR posts.created_at < $10::timestamptz)
+  AND (
+    $11::timestamptz IS NULL
+    OR (posts.created_at, posts.id) < ($11::timestamptz, $12::uuid)
+  )
+ORDER BY posts.created_at DESC, posts.id DESC
+LIMIT $13
+OFFSET $14
+`
+
+type GetPostsForUserByFetchedParams struct {
+	UserID       uuid.UUID
+	Feed         sql.NullString
+	Author       sql.NullString
+	UnreadOnly   bool
+	Query        sql.NullString
+	Folder       sql.NullString
+	Tag          sql.NullString
+	ShowFiltered bool
+	Since        sql.NullTime
+	Before       sql.NullTime
+	CursorTime   sql.NullTime
+	CursorID     uuid.NullUUID
+	Limit        int32
+	Offset       int32
+}
+
+type GetPostsForUserByFetchedRow struct {
+	ID          uuid.UUID
+	FeedID      uuid.UUID
+	Title       string
+	Url         string
+	Description string
+	PublishedAt sql.NullTime
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Author      string
+	Content     string
+	Categories  []string
+	FeedName    string
+	FeedUrl     string
+	IsRead      bool
+	IsStarred   bool
+	IsFiltered  bool
+	Tags        []string
+	SortKey     time.Time
+}
+
+func (q *Queries) GetPostsForUserByFetched(ctx context.Context, arg GetPostsForUserByFetchedParams) ([]GetPostsForUserByFetchedRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsForUserByFetched,
+		arg.UserID,
+		arg.Feed,
+		arg.Author,
+		arg.UnreadOnly,
+		arg.Query,
+		arg.Folder,
+		arg.Tag,
+		arg.ShowFiltered,
+		arg.Since,
+		arg.Before,
+		arg.CursorTime,
+		arg.CursorID,
+		arg.Limit,
+		arg.Offset,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Post
+	var items []GetPostsForUserByFetchedRow
 	for rows.Next() {
-		var i Post
+		var i GetPostsForUserByFetchedRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.FeedID,
@@ -87,6 +418,113 @@ func (q *Queries) GetPostForUser(ctx context.Context, arg GetPostForUserParams)
 			&i.PublishedAt,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Author,
+			&i.Content,
+			pq.Array(&i.Categories),
+			&i.FeedName,
+			&i.FeedUrl,
+			&i.IsRead,
+			&i.IsStarred,
+			&i.IsFiltered,
+			pq.Array(&i.Tags),
+			&i.SortKey,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchPostsForUser = `-- name: SearchPostsForUser :many
+SELECT
+  posts.id,
+  posts.feed_id,
+  posts.title,
+  posts.url,
+  posts.author,
+  posts.published_at,
+  feeds.name AS feed_name,
+  post_is_filtered(feed_follows.user_id, posts.id)::boolean AS is_filtered,
+  ts_rank(posts.search_vector, websearch_to_tsquery('english', $1::text))::real AS rank,
+  ts_headline(
+    'english',
+    posts.title,
+    websearch_to_tsquery('english', $1::text),
+    'HighlightAll=true, StartSel=[[, StopSel=]]'
+  )::text AS title_headline,
+  ts_headline(
+    'english',
+    posts.description || ' ' || posts.content,
+    websearch_to_tsquery('english', $1::text),
+    'MaxFragments=2, MaxWords=25, MinWords=10, FragmentDelimiter=" ... ", StartSel=[[, StopSel=]]'
+  )::text AS snippet
+FROM posts
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+INNER JOIN feeds ON feeds.id = posts.feed_id
+WHERE feed_follows.user_id = $2
+  AND posts.search_vector @@ websearch_to_tsquery('english', $1::text)
+  AND ($3::boolean OR NOT post_is_filtered(feed_follows.user_id, posts.id))
+ORDER BY rank DESC, posts.published_at DESC NULLS LAST, posts.id DESC
+LIMIT $4
+OFFSET $5
+`
+
+type SearchPostsForUserParams struct {
+	Query        string
+	UserID       uuid.UUID
+	ShowFiltered bool
+	Limit        int32
+	Offset       int32
+}
+
+type SearchPostsForUserRow struct {
+	ID            uuid.UUID
+	FeedID        uuid.UUID
+	Title         string
+	Url           string
+	Author        string
+	PublishedAt   sql.NullTime
+	FeedName      string
+	IsFiltered    bool
+	Rank          float32
+	TitleHeadline string
+	Snippet       string
+}
+
+func (q *Queries) SearchPostsForUser(ctx context.Context, arg SearchPostsForUserParams) ([]SearchPostsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchPostsForUser,
+		arg.Query,
+		arg.UserID,
+		arg.ShowFiltered,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchPostsForUserRow
+	for rows.Next() {
+		var i SearchPostsForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.FeedID,
+			&i.Title,
+			&i.Url,
+			&i.Author,
+			&i.PublishedAt,
+			&i.FeedName,
+			&i.IsFiltered,
+			&i.Rank,
+			&i.TitleHeadline,
+			&i.Snippet,
 		); err != nil {
 			return nil, err
 		}
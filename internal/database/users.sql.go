@@ -12,17 +12,41 @@ import (
 	"github.com/google/uuid"
 )
 
+const countAdmins = `-- name: CountAdmins :one
+SELECT COUNT(*) FROM users WHERE role = 'admin'
+`
+
+func (q *Queries) CountAdmins(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countAdmins)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countUsers = `-- name: CountUsers :one
+SELECT COUNT(*) FROM users
+`
+
+func (q *Queries) CountUsers(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countUsers)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (id, name, created_at, updated_at)
-VALUES ($1, $2, $3, $4)
-RETURNING id, name, created_at, updated_at
+INSERT INTO users (id, name, created_at, updated_at, password_hash, role)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, name, created_at, updated_at, password_hash, role
 `
 
 type CreateUserParams struct {
-	ID        uuid.UUID
-	Name      string
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID           uuid.UUID
+	Name         string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	PasswordHash string
+	Role         string
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
@@ -31,6 +55,8 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		arg.Name,
 		arg.CreatedAt,
 		arg.UpdatedAt,
+		arg.PasswordHash,
+		arg.Role,
 	)
 	var i User
 	err := row.Scan(
@@ -38,6 +64,8 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Name,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.PasswordHash,
+		&i.Role,
 	)
 	return i, err
 }
@@ -51,8 +79,20 @@ func (q *Queries) DeleteAllUsers(ctx context.Context) error {
 	return err
 }
 
+const deleteUser = `-- name: DeleteUser :execrows
+DELETE FROM users WHERE id = $1
+`
+
+func (q *Queries) DeleteUser(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUser, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const findUserByeName = `-- name: FindUserByeName :one
-SELECT id, name, created_at, updated_at FROM users WHERE name = $1 ORDER BY created_at DESC LIMIT 1
+SELECT id, name, created_at, updated_at, password_hash, role FROM users WHERE name = $1 ORDER BY created_at DESC LIMIT 1
 `
 
 func (q *Queries) FindUserByeName(ctx context.Context, name string) (User, error) {
@@ -63,12 +103,47 @@ func (q *Queries) FindUserByeName(ctx context.Context, name string) (User, error
 		&i.Name,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.PasswordHash,
+		&i.Role,
+	)
+	return i, err
+}
+
+const getUserStats = `-- name: GetUserStats :one
+SELECT
+  (SELECT COUNT(*) FROM feed_follows WHERE feed_follows.user_id = $1) AS follows,
+  (SELECT COUNT(*) FROM feeds WHERE feeds.user_id = $1) AS feeds_owned,
+  (
+    SELECT COUNT(*)
+    FROM posts
+    INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = $1
+    LEFT JOIN post_reads ON post_reads.post_id = posts.id AND post_reads.user_id = $1
+    WHERE post_reads.post_id IS NULL
+  ) AS unread_posts,
+  (SELECT COUNT(*) FROM bookmarks WHERE bookmarks.user_id = $1) AS starred_posts
+`
+
+type GetUserStatsRow struct {
+	Follows      int64
+	FeedsOwned   int64
+	UnreadPosts  int64
+	StarredPosts int64
+}
+
+func (q *Queries) GetUserStats(ctx context.Context, userID uuid.UUID) (GetUserStatsRow, error) {
+	row := q.db.QueryRowContext(ctx, getUserStats, userID)
+	var i GetUserStatsRow
+	err := row.Scan(
+		&i.Follows,
+		&i.FeedsOwned,
+		&i.UnreadPosts,
+		&i.StarredPosts,
 	)
 	return i, err
 }
 
 const getUsers = `-- name: GetUsers :many
-SELECT id, name, created_at, updated_at FROM users
+SELECT id, name, created_at, updated_at, password_hash, role FROM users
 `
 
 func (q *Queries) GetUsers(ctx context.Context) ([]User, error) {
@@ -85,6 +160,8 @@ func (q *Queries) GetUsers(ctx context.Context) ([]User, error) {
 			&i.Name,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.PasswordHash,
+			&i.Role,
 		); err != nil {
 			return nil, err
 		}
@@ -98,3 +175,57 @@ func (q *Queries) GetUsers(ctx context.Context) ([]User, error) {
 	}
 	return items, nil
 }
+
+const lockUsers = `-- name: LockUsers :exec
+LOCK TABLE users IN SHARE ROW EXCLUSIVE MODE
+`
+
+func (q *Queries) LockUsers(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, lockUsers)
+	return err
+}
+
+const renameUser = `-- name: RenameUser :exec
+UPDATE users SET name = $1, updated_at = $2 WHERE id = $3
+`
+
+type RenameUserParams struct {
+	Name      string
+	UpdatedAt time.Time
+	ID        uuid.UUID
+}
+
+func (q *Queries) RenameUser(ctx context.Context, arg RenameUserParams) error {
+	_, err := q.db.ExecContext(ctx, renameUser, arg.Name, arg.UpdatedAt, arg.ID)
+	return err
+}
+
+const setUserPassword = `-- name: SetUserPassword :exec
+UPDATE users SET password_hash = $1, updated_at = $2 WHERE id = $3
+`
+
+type SetUserPasswordParams struct {
+	PasswordHash string
+	UpdatedAt    time.Time
+	ID           uuid.UUID
+}
+
+func (q *Queries) SetUserPassword(ctx context.Context, arg SetUserPasswordParams) error {
+	_, err := q.db.ExecContext(ctx, setUserPassword, arg.PasswordHash, arg.UpdatedAt, arg.ID)
+	return err
+}
+
+const setUserRole = `-- name: SetUserRole :exec
+UPDATE users SET role = $1, updated_at = $2 WHERE id = $3
+`
+
+type SetUserRoleParams struct {
+	Role      string
+	UpdatedAt time.Time
+	ID        uuid.UUID
+}
+
+func (q *Queries) SetUserRole(ctx context.Context, arg SetUserRoleParams) error {
+	_, err := q.db.ExecContext(ctx, setUserRole, arg.Role, arg.UpdatedAt, arg.ID)
+	return err
+}
@@ -0,0 +1,259 @@
+// Package email builds multipart emails and sends them over SMTP.
+package email
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TLS modes of a server.
+const (
+	TLSStartTLS = "starttls"
+	TLSImplicit = "tls"
+	TLSNone     = "none"
+)
+
+// DefaultTimeout limits a whole SMTP session, from dialing to QUIT, when the
+// server has no timeout of its own.
+const DefaultTimeout = 2 * time.Minute
+
+var ErrNoStartTLS = errors.New("the SMTP server does not support STARTTLS")
+
+// Server is an SMTP server and the credentials to log in with. Servers
+// without a username are used without authentication.
+type Server struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	TLS      string
+	// Timeout limits the whole session, zero means DefaultTimeout
+	Timeout time.Duration
+}
+
+// Message is an email with a plain text and an HTML body. Mail clients show
+// the HTML body and fall back to the text.
+type Message struct {
+	From    string
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+	Date    time.Time
+}
+
+// Bytes encodes the message as a multipart/alternative MIME message with CRLF
+// line endings.
+func (m *Message) Bytes() ([]byte, error) {
+	from, err := mail.ParseAddress(m.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender %q: %v", m.From, err)
+	}
+	to, err := mail.ParseAddress(m.To)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recipient %q: %v", m.To, err)
+	}
+
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+
+	for _, part := range []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=utf-8", m.Text},
+		{"text/html; charset=utf-8", m.HTML},
+	} {
+		writer, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		encoder := quotedprintable.NewWriter(writer)
+		if _, err := encoder.Write([]byte(crlf(part.content))); err != nil {
+			return nil, err
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	date := m.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+
+	messageID, err := newMessageID(from.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	var message bytes.Buffer
+	headers := [][2]string{
+		{"From", from.String()},
+		{"To", to.String()},
+		{"Subject", mime.QEncoding.Encode("utf-8", m.Subject)},
+		{"Date", date.Format(time.RFC1123Z)},
+		{"Message-ID", messageID},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "multipart/alternative; boundary=" + parts.Boundary()},
+	}
+	for _, header := range headers {
+		fmt.Fprintf(&message, "%v: %v\r\n", header[0], header[1])
+	}
+	message.WriteString("\r\n")
+	message.Write(body.Bytes())
+
+	return message.Bytes(), nil
+}
+
+func crlf(text string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\n", "\r\n")
+}
+
+func newMessageID(sender string) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+
+	domain := "localhost"
+	if at := strings.LastIndex(sender, "@"); at >= 0 {
+		domain = sender[at+1:]
+	}
+
+	return fmt.Sprintf("<%v@%v>", hex.EncodeToString(random), domain), nil
+}
+
+// Send delivers the message through the server.
+func Send(server Server, message *Message) error {
+	data, err := message.Bytes()
+	if err != nil {
+		return err
+	}
+
+	// Bytes validated both addresses
+	from, _ := mail.ParseAddress(message.From)
+	to, _ := mail.ParseAddress(message.To)
+
+	client, err := dial(server)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if server.Username != "" {
+		// PlainAuth refuses to send the password without TLS, except to localhost
+		auth := smtp.PlainAuth("", server.Username, server.Password, server.Host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("failed to authenticate: %v", err)
+		}
+	}
+
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return err
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(data); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+// dial connects to the server. The deadline of the connection covers the
+// whole session, so a server which stops responding cannot hang the caller.
+func dial(server Server) (*smtp.Client, error) {
+	addr := net.JoinHostPort(server.Host, strconv.Itoa(server.Port))
+	tlsConfig := &tls.Config{ServerName: server.Host}
+
+	timeout := server.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	deadline := time.Now().Add(timeout)
+	dialer := &net.Dialer{Deadline: deadline}
+
+	switch server.TLS {
+	case TLSImplicit:
+		conn, err := tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+		if err != nil {
+			return nil, err
+		}
+		if err := conn.SetDeadline(deadline); err != nil {
+			conn.Close()
+			return nil, err
+		}
+
+		client, err := smtp.NewClient(conn, server.Host)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+
+		return client, nil
+	case TLSStartTLS, "", TLSNone:
+		conn, err := dialer.Dial("tcp", addr)
+		if err != nil {
+			return nil, err
+		}
+		// StartTLS wraps the connection, the deadline carries over
+		if err := conn.SetDeadline(deadline); err != nil {
+			conn.Close()
+			return nil, err
+		}
+
+		client, err := smtp.NewClient(conn, server.Host)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+
+		if server.TLS == TLSNone {
+			return client, nil
+		}
+
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			client.Close()
+			return nil, ErrNoStartTLS
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, err
+		}
+
+		return client, nil
+	}
+
+	return nil, fmt.Errorf("unknown TLS mode %q, valid modes are %q, %q and %q", server.TLS, TLSStartTLS, TLSImplicit, TLSNone)
+}
@@ -0,0 +1,294 @@
+package email
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpServer is a minimal in-process SMTP server which records the messages
+// it accepts. rejectData makes it refuse every message after DATA.
+type smtpServer struct {
+	listener   net.Listener
+	rejectData bool
+	messages   chan string
+}
+
+func startSMTPServer(t *testing.T, rejectData bool) (*smtpServer, Server) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	server := &smtpServer{
+		listener:   listener,
+		rejectData: rejectData,
+		messages:   make(chan string, 10),
+	}
+	go server.serve()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	return server, Server{Host: "127.0.0.1", Port: addr.Port, TLS: TLSNone}
+}
+
+func (s *smtpServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *smtpServer) handle(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	reader := bufio.NewReader(conn)
+	reply := func(line string) {
+		io.WriteString(conn, line+"\r\n")
+	}
+
+	reply("220 localhost ESMTP test")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		verb := strings.ToUpper(strings.Fields(line + " x")[0])
+
+		switch verb {
+		case "EHLO", "HELO":
+			reply("250 localhost")
+		case "MAIL", "RCPT", "RSET", "NOOP":
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+
+			var data strings.Builder
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(line, "."))
+			}
+
+			if s.rejectData {
+				reply("554 Transaction failed")
+				continue
+			}
+			s.messages <- data.String()
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+func TestSend(t *testing.T) {
+	smtp, server := startSMTPServer(t, false)
+
+	message := &Message{
+		From:    "Gator <gator@example.com>",
+		To:      "reader@example.com",
+		Subject: "Your daily gator digest: 3 new posts ☕",
+		Text:    "Hello Zoë\n\n* First post\n  https://example.com/first\n",
+		HTML:    "<p>Hello Zoë</p>\n<a href=\"https://example.com/first\">First post</a>\n",
+		Date:    time.Date(2024, 10, 1, 8, 0, 0, 0, time.UTC),
+	}
+
+	if err := Send(server, message); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	var raw string
+	select {
+	case raw = <-smtp.messages:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the server did not receive a message")
+	}
+
+	parsed, err := mail.ReadMessage(strings.NewReader(raw))
+	if err != nil {
+		t.Fatalf("failed to parse message: %v", err)
+	}
+
+	rawSubject := parsed.Header.Get("Subject")
+	if !strings.HasPrefix(rawSubject, "=?utf-8?q?") {
+		t.Errorf("Subject = %q, want a Q-encoded word", rawSubject)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(rawSubject)
+	if err != nil {
+		t.Fatalf("failed to decode subject %q: %v", rawSubject, err)
+	}
+	if subject != message.Subject {
+		t.Errorf("Subject = %q, want %q", subject, message.Subject)
+	}
+
+	headers := map[string]string{
+		"From":         `"Gator" <gator@example.com>`,
+		"To":           "<reader@example.com>",
+		"Date":         "Tue, 01 Oct 2024 08:00:00 +0000",
+		"MIME-Version": "1.0",
+	}
+	for name, want := range headers {
+		if got := parsed.Header.Get(name); got != want {
+			t.Errorf("%v = %q, want %q", name, got, want)
+		}
+	}
+	if messageID := parsed.Header.Get("Message-ID"); !strings.HasSuffix(messageID, "@example.com>") {
+		t.Errorf("Message-ID = %q, want one in the sender domain", messageID)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("failed to parse Content-Type: %v", err)
+	}
+	if mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q, want multipart/alternative", mediaType)
+	}
+
+	wantParts := []struct {
+		contentType string
+		body        string
+	}{
+		{"text/plain; charset=utf-8", crlf(message.Text)},
+		{"text/html; charset=utf-8", crlf(message.HTML)},
+	}
+
+	parts := multipart.NewReader(parsed.Body, params["boundary"])
+	for _, want := range wantParts {
+		// NextRawPart keeps the Content-Transfer-Encoding header
+		part, err := parts.NextRawPart()
+		if err != nil {
+			t.Fatalf("failed to read %v part: %v", want.contentType, err)
+		}
+
+		if got := part.Header.Get("Content-Type"); got != want.contentType {
+			t.Errorf("part Content-Type = %q, want %q", got, want.contentType)
+		}
+		if got := part.Header.Get("Content-Transfer-Encoding"); got != "quoted-printable" {
+			t.Errorf("%v Content-Transfer-Encoding = %q, want quoted-printable", want.contentType, got)
+		}
+
+		body, err := io.ReadAll(quotedprintable.NewReader(part))
+		if err != nil {
+			t.Fatalf("failed to decode %v part: %v", want.contentType, err)
+		}
+		if string(body) != want.body {
+			t.Errorf("%v part = %q, want %q", want.contentType, body, want.body)
+		}
+	}
+
+	if _, err := parts.NextRawPart(); err != io.EOF {
+		t.Errorf("got more than two parts, want EOF, got %v", err)
+	}
+}
+
+func TestSendRejected(t *testing.T) {
+	_, server := startSMTPServer(t, true)
+
+	err := Send(server, &Message{
+		From:    "gator@example.com",
+		To:      "reader@example.com",
+		Subject: "Digest",
+		Text:    "text",
+		HTML:    "<p>html</p>",
+	})
+	if err == nil {
+		t.Fatal("Send succeeded, want the rejection of the server")
+	}
+	if !strings.Contains(err.Error(), "554") {
+		t.Errorf("Send error = %q, want the 554 reply", err)
+	}
+}
+
+func TestSendInvalidAddress(t *testing.T) {
+	tests := []struct {
+		from string
+		to   string
+		want string
+	}{
+		{"not an address", "reader@example.com", "invalid sender"},
+		{"gator@example.com", "", "invalid recipient"},
+	}
+
+	for _, tt := range tests {
+		// Addresses are checked before dialing, so no server is needed
+		err := Send(Server{Host: "127.0.0.1", Port: 1, TLS: TLSNone}, &Message{From: tt.from, To: tt.to})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Send(from %q, to %q) error = %v, want %q", tt.from, tt.to, err, tt.want)
+		}
+	}
+}
+
+func TestSendUnknownTLSMode(t *testing.T) {
+	err := Send(Server{Host: "127.0.0.1", Port: 1, TLS: "ssl"}, &Message{From: "gator@example.com", To: "reader@example.com"})
+	if err == nil || !strings.Contains(err.Error(), `unknown TLS mode "ssl"`) {
+		t.Errorf("Send error = %v, want an unknown TLS mode error", err)
+	}
+}
+
+func TestSendTimeout(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	// The server accepts connections but never greets
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			// Held open until the client gives up and closes it
+			go func() {
+				io.Copy(io.Discard, conn)
+				conn.Close()
+			}()
+		}
+	}()
+
+	server := Server{
+		Host:    "127.0.0.1",
+		Port:    listener.Addr().(*net.TCPAddr).Port,
+		TLS:     TLSNone,
+		Timeout: 200 * time.Millisecond,
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- Send(server, &Message{From: "gator@example.com", To: "reader@example.com"})
+	}()
+
+	select {
+	case err := <-done:
+		var netErr net.Error
+		if !errors.As(err, &netErr) || !netErr.Timeout() {
+			t.Errorf("Send error = %v, want a timeout", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Send did not time out")
+	}
+}
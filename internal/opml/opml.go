@@ -0,0 +1,179 @@
+package opml
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html/charset"
+)
+
+var ErrNotOPML = errors.New("not an OPML document")
+
+type OPML struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    Head     `xml:"head"`
+	Body    Body     `xml:"body"`
+}
+
+type Head struct {
+	Title       string `xml:"title,omitempty"`
+	DateCreated string `xml:"dateCreated,omitempty"`
+	OwnerName   string `xml:"ownerName,omitempty"`
+}
+
+type Body struct {
+	Outlines []Outline `xml:"outline"`
+}
+
+type Outline struct {
+	Text     string    `xml:"text,attr"`
+	Title    string    `xml:"title,attr,omitempty"`
+	Type     string    `xml:"type,attr,omitempty"`
+	XMLURL   string    `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL  string    `xml:"htmlUrl,attr,omitempty"`
+	Outlines []Outline `xml:"outline"`
+}
+
+// Name returns the title of the outline, falling back to its text.
+func (o Outline) Name() string {
+	if title := strings.TrimSpace(o.Title); title != "" {
+		return title
+	}
+
+	return strings.TrimSpace(o.Text)
+}
+
+// IsFeed reports whether the outline is a subscription rather than a folder.
+func (o Outline) IsFeed() bool {
+	return o.XMLURL != "" || o.Type == "rss"
+}
+
+// Feed is a subscription found in an OPML document, together with the folder
+// it was nested in. Nested folders are joined with "/", feeds at the top level
+// have no folder.
+type Feed struct {
+	Folder  string
+	Title   string
+	XMLURL  string
+	HTMLURL string
+}
+
+// Parse reads an OPML document.
+func Parse(r io.Reader) (*OPML, error) {
+	var doc OPML
+
+	decoder := xml.NewDecoder(r)
+	// OPML exports are mostly UTF-8, but some readers still write Latin-1 or
+	// windows-1252. Those are decoded, unknown charsets are rejected.
+	decoder.CharsetReader = func(label string, input io.Reader) (io.Reader, error) {
+		reader, err := charset.NewReaderLabel(label, input)
+		if err != nil {
+			return nil, fmt.Errorf("unsupported charset %q", label)
+		}
+
+		return reader, nil
+	}
+
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotOPML, err)
+	}
+
+	return &doc, nil
+}
+
+// Feeds flattens the outline tree into the list of subscriptions in document
+// order.
+func (o *OPML) Feeds() []Feed {
+	var feeds []Feed
+	collectFeeds(o.Body.Outlines, "", &feeds)
+	return feeds
+}
+
+func collectFeeds(outlines []Outline, folder string, feeds *[]Feed) {
+	for _, outline := range outlines {
+		if outline.IsFeed() {
+			*feeds = append(*feeds, Feed{
+				Folder:  folder,
+				Title:   outline.Name(),
+				XMLURL:  strings.TrimSpace(outline.XMLURL),
+				HTMLURL: strings.TrimSpace(outline.HTMLURL),
+			})
+			continue
+		}
+
+		subFolder := outline.Name()
+		if folder != "" && subFolder != "" {
+			subFolder = folder + "/" + subFolder
+		} else if subFolder == "" {
+			subFolder = folder
+		}
+
+		collectFeeds(outline.Outlines, subFolder, feeds)
+	}
+}
+
+// New creates an empty OPML 2.0 document.
+func New(title string, ownerName string, dateCreated time.Time) *OPML {
+	return &OPML{
+		Version: "2.0",
+		Head: Head{
+			Title:       title,
+			DateCreated: dateCreated.Format(time.RFC1123Z),
+			OwnerName:   ownerName,
+		},
+	}
+}
+
+// AddFeed adds a subscription to the document, nesting it into the outlines
+// of its folder. Folder outlines are created as needed, "Tech/Go" becomes a
+// "Go" outline inside a "Tech" outline.
+func (o *OPML) AddFeed(feed Feed) {
+	outlines := &o.Body.Outlines
+
+	if feed.Folder != "" {
+		for _, name := range strings.Split(feed.Folder, "/") {
+			outlines = folderOutlines(outlines, name)
+		}
+	}
+
+	*outlines = append(*outlines, Outline{
+		Text:    feed.Title,
+		Title:   feed.Title,
+		Type:    "rss",
+		XMLURL:  feed.XMLURL,
+		HTMLURL: feed.HTMLURL,
+	})
+}
+
+func folderOutlines(outlines *[]Outline, name string) *[]Outline {
+	for i := range *outlines {
+		outline := &(*outlines)[i]
+		if !outline.IsFeed() && outline.Name() == name {
+			return &outline.Outlines
+		}
+	}
+
+	*outlines = append(*outlines, Outline{Text: name, Title: name})
+	return &(*outlines)[len(*outlines)-1].Outlines
+}
+
+// Encode writes the document as indented XML.
+func (o *OPML) Encode(w io.Writer) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(o); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,150 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenRegex
+	tokenOperator
+	tokenComma
+	tokenLParen
+	tokenRParen
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	// flags holds the flags of a regex literal, e.g. "i" for /go/i
+	flags string
+	pos   int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of rule"
+	case tokenString:
+		return fmt.Sprintf("%q", t.value)
+	case tokenRegex:
+		return fmt.Sprintf("/%v/%v", t.value, t.flags)
+	}
+
+	return fmt.Sprintf("%q", t.value)
+}
+
+// tokenize splits a rule into tokens. Identifiers are lower-cased so keywords
+// and field names are case-insensitive, string and regex literals are kept as
+// written.
+func tokenize(src string) ([]token, error) {
+	var tokens []token
+	runes := []rune(src)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == ',':
+			tokens = append(tokens, token{kind: tokenComma, value: ",", pos: i})
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, value: "(", pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, value: ")", pos: i})
+			i++
+		case r == '=':
+			tokens = append(tokens, token{kind: tokenOperator, value: "=", pos: i})
+			i++
+		case r == '~':
+			tokens = append(tokens, token{kind: tokenOperator, value: "~", pos: i})
+			i++
+		case r == '!':
+			if i+1 < len(runes) && (runes[i+1] == '=' || runes[i+1] == '~') {
+				tokens = append(tokens, token{kind: tokenOperator, value: string(runes[i : i+2]), pos: i})
+				i += 2
+				continue
+			}
+			return nil, fmt.Errorf("unexpected %q at position %v", r, i)
+		case r == '"' || r == '\'':
+			value, next, err := readQuoted(runes, i, r)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenString, value: value, pos: i})
+			i = next
+		case r == '/':
+			value, next, err := readQuoted(runes, i, '/')
+			if err != nil {
+				return nil, err
+			}
+			start := next
+			for next < len(runes) && unicode.IsLetter(runes[next]) {
+				next++
+			}
+			tokens = append(tokens, token{kind: tokenRegex, value: value, flags: string(runes[start:next]), pos: i})
+			i = next
+		case isIdentRune(r):
+			start := i
+			for i < len(runes) && isIdentRune(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, value: strings.ToLower(string(runes[start:i])), pos: start})
+		default:
+			return nil, fmt.Errorf("unexpected %q at position %v", r, i)
+		}
+	}
+
+	tokens = append(tokens, token{kind: tokenEOF, pos: len(runes)})
+
+	return tokens, nil
+}
+
+func isIdentRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' || r == '.'
+}
+
+// readQuoted reads a literal delimited by quote starting at runes[start]. A
+// backslash escapes the delimiter; for regular expressions every other
+// escape sequence is kept untouched.
+func readQuoted(runes []rune, start int, quote rune) (string, int, error) {
+	var value strings.Builder
+
+	for i := start + 1; i < len(runes); i++ {
+		r := runes[i]
+
+		if r == '\\' && i+1 < len(runes) {
+			next := runes[i+1]
+			if next == quote {
+				value.WriteRune(next)
+			} else if quote == '/' {
+				value.WriteRune(r)
+				value.WriteRune(next)
+			} else if next == '\\' {
+				value.WriteRune(next)
+			} else {
+				value.WriteRune(r)
+				value.WriteRune(next)
+			}
+			i++
+			continue
+		}
+
+		if r == quote {
+			return value.String(), i + 1, nil
+		}
+
+		value.WriteRune(r)
+	}
+
+	return "", 0, fmt.Errorf("unterminated literal starting at position %v", start)
+}
@@ -0,0 +1,110 @@
+package rules
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTokenizeQuotedStrings(t *testing.T) {
+	tests := []struct {
+		name  string
+		src   string
+		kind  tokenKind
+		value string
+		flags string
+	}{
+		{"double quotes", `"Go Blog"`, tokenString, "Go Blog", ""},
+		{"single quotes", `'Go Blog'`, tokenString, "Go Blog", ""},
+		{"escaped double quote", `"say \"hi\""`, tokenString, `say "hi"`, ""},
+		{"escaped single quote", `'it\'s'`, tokenString, "it's", ""},
+		{"other quote kept", `"it's"`, tokenString, "it's", ""},
+		{"escaped backslash", `"C:\\go"`, tokenString, `C:\go`, ""},
+		{"unknown escape kept", `"a\nb"`, tokenString, `a\nb`, ""},
+		{"keeps case", `"MiXeD"`, tokenString, "MiXeD", ""},
+		{"empty", `""`, tokenString, "", ""},
+		{"unicode", `"Café ☕"`, tokenString, "Café ☕", ""},
+		{"regex", `/go+/`, tokenRegex, "go+", ""},
+		{"regex with flags", `/go/i`, tokenRegex, "go", "i"},
+		{"regex escaped slash", `/a\/b/`, tokenRegex, "a/b", ""},
+		{"regex keeps escapes", `/\d+\.\d+/`, tokenRegex, `\d+\.\d+`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, err := tokenize(tt.src)
+			if err != nil {
+				t.Fatalf("tokenize(%q) failed: %v", tt.src, err)
+			}
+			if len(tokens) != 2 || tokens[1].kind != tokenEOF {
+				t.Fatalf("tokenize(%q) = %v, want a single token", tt.src, tokens)
+			}
+
+			got := tokens[0]
+			if got.kind != tt.kind || got.value != tt.value || got.flags != tt.flags {
+				t.Errorf("tokenize(%q) = {%v %q %q}, want {%v %q %q}", tt.src, got.kind, got.value, got.flags, tt.kind, tt.value, tt.flags)
+			}
+		})
+	}
+}
+
+func TestTokenizeIdentifiersAndOperators(t *testing.T) {
+	tokens, err := tokenize(`IF Title != "x" AND feed !~ /y/ then TAG go-1.23, star`)
+	if err != nil {
+		t.Fatalf("tokenize failed: %v", err)
+	}
+
+	want := []struct {
+		kind  tokenKind
+		value string
+		pos   int
+	}{
+		{tokenIdent, "if", 0},
+		{tokenIdent, "title", 3},
+		{tokenOperator, "!=", 9},
+		{tokenString, "x", 12},
+		{tokenIdent, "and", 16},
+		{tokenIdent, "feed", 20},
+		{tokenOperator, "!~", 25},
+		{tokenRegex, "y", 28},
+		{tokenIdent, "then", 32},
+		{tokenIdent, "tag", 37},
+		{tokenIdent, "go-1.23", 41},
+		{tokenComma, ",", 48},
+		{tokenIdent, "star", 50},
+		{tokenEOF, "", 54},
+	}
+
+	if len(tokens) != len(want) {
+		t.Fatalf("got %v tokens, want %v: %v", len(tokens), len(want), tokens)
+	}
+	for i, w := range want {
+		got := tokens[i]
+		if got.kind != w.kind || got.value != w.value || got.pos != w.pos {
+			t.Errorf("token %v = {%v %q %v}, want {%v %q %v}", i, got.kind, got.value, got.pos, w.kind, w.value, w.pos)
+		}
+	}
+}
+
+func TestTokenizeErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{`title = "open`, "unterminated literal starting at position 8"},
+		{`title ~ /open`, "unterminated literal starting at position 8"},
+		{`title ! "x"`, `unexpected '!' at position 6`},
+		{`title = "x" & star`, `unexpected '&' at position 12`},
+		{`"ä" ; x`, `unexpected ';' at position 4`},
+	}
+
+	for _, tt := range tests {
+		_, err := tokenize(tt.src)
+		if err == nil {
+			t.Errorf("tokenize(%q) succeeded, want error %q", tt.src, tt.want)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("tokenize(%q) error = %q, want %q", tt.src, err, tt.want)
+		}
+	}
+}
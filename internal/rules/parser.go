@@ -0,0 +1,223 @@
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+)
+
+// Parse parses a rule of the form "if <condition> then <action>[, <action>]".
+func Parse(src string) (*Rule, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rule: %v", err)
+	}
+
+	p := &parser{tokens: tokens}
+	rule, err := p.parseRule()
+	if err != nil {
+		return nil, fmt.Errorf("invalid rule: %v", err)
+	}
+
+	return rule, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) isKeyword(keyword string) bool {
+	t := p.peek()
+	return t.kind == tokenIdent && t.value == keyword
+}
+
+func (p *parser) expectKeyword(keyword string) error {
+	t := p.next()
+	if t.kind != tokenIdent || t.value != keyword {
+		return fmt.Errorf("expected %q at position %v, got %v", keyword, t.pos, t)
+	}
+	return nil
+}
+
+func (p *parser) parseRule() (*Rule, error) {
+	if err := p.expectKeyword("if"); err != nil {
+		return nil, err
+	}
+
+	cond, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.expectKeyword("then"); err != nil {
+		return nil, err
+	}
+
+	actions, err := p.parseActions()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %v at position %v", t, t.pos)
+	}
+
+	return &Rule{condition: cond, Actions: actions}, nil
+}
+
+func (p *parser) parseOr() (condition, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isKeyword("or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orCondition{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAnd() (condition, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isKeyword("and") {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andCondition{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseUnary() (condition, error) {
+	if p.isKeyword("not") {
+		p.next()
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notCondition{inner: inner}, nil
+	}
+
+	if p.peek().kind == tokenLParen {
+		p.next()
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if t := p.next(); t.kind != tokenRParen {
+			return nil, fmt.Errorf("expected \")\" at position %v, got %v", t.pos, t)
+		}
+		return inner, nil
+	}
+
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (condition, error) {
+	fieldToken := p.next()
+	if fieldToken.kind != tokenIdent || !slices.Contains(fields, fieldToken.value) {
+		return nil, fmt.Errorf("expected a field (%v) at position %v, got %v", fields, fieldToken.pos, fieldToken)
+	}
+
+	operatorToken := p.next()
+	operator := operatorToken.value
+	if operatorToken.kind != tokenOperator && !(operatorToken.kind == tokenIdent && operator == "contains") {
+		return nil, fmt.Errorf("expected an operator (=, !=, contains, ~, !~) at position %v, got %v", operatorToken.pos, operatorToken)
+	}
+
+	valueToken := p.next()
+	cmp := comparison{field: fieldToken.value, operator: operator}
+
+	if operator == "~" || operator == "!~" {
+		if valueToken.kind != tokenRegex && valueToken.kind != tokenString {
+			return nil, fmt.Errorf("expected a regular expression at position %v, got %v", valueToken.pos, valueToken)
+		}
+
+		expr := valueToken.value
+		for _, flag := range valueToken.flags {
+			if flag != 'i' {
+				return nil, fmt.Errorf("unsupported regular expression flag %q at position %v", flag, valueToken.pos)
+			}
+			expr = "(?i)" + expr
+		}
+
+		pattern, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression at position %v: %v", valueToken.pos, err)
+		}
+
+		cmp.value = valueToken.value
+		cmp.pattern = pattern
+		return cmp, nil
+	}
+
+	if valueToken.kind != tokenString && valueToken.kind != tokenIdent {
+		return nil, fmt.Errorf("expected a string at position %v, got %v", valueToken.pos, valueToken)
+	}
+	cmp.value = valueToken.value
+
+	return cmp, nil
+}
+
+func (p *parser) parseActions() ([]Action, error) {
+	var actions []Action
+
+	for {
+		action, err := p.parseAction()
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, action)
+
+		if p.peek().kind != tokenComma {
+			return actions, nil
+		}
+		p.next()
+	}
+}
+
+func (p *parser) parseAction() (Action, error) {
+	t := p.next()
+	if t.kind != tokenIdent {
+		return Action{}, fmt.Errorf("expected an action (tag, star, markread) at position %v, got %v", t.pos, t)
+	}
+
+	switch ActionKind(t.value) {
+	case ActionStar, ActionMarkRead:
+		return Action{Kind: ActionKind(t.value)}, nil
+	case ActionTag:
+		tag := p.next()
+		if (tag.kind != tokenIdent && tag.kind != tokenString) || NormalizeTag(tag.value) == "" {
+			return Action{}, fmt.Errorf("expected a tag name at position %v, got %v", tag.pos, tag)
+		}
+		return Action{Kind: ActionTag, Tag: NormalizeTag(tag.value)}, nil
+	}
+
+	return Action{}, fmt.Errorf("unknown action %v at position %v", t, t.pos)
+}
@@ -0,0 +1,168 @@
+package rules
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// describe renders a condition as a fully parenthesised expression so tests
+// can assert how the parser grouped it.
+func describe(c condition) string {
+	switch c := c.(type) {
+	case andCondition:
+		return fmt.Sprintf("(%v and %v)", describe(c.left), describe(c.right))
+	case orCondition:
+		return fmt.Sprintf("(%v or %v)", describe(c.left), describe(c.right))
+	case notCondition:
+		return fmt.Sprintf("(not %v)", describe(c.inner))
+	case comparison:
+		return fmt.Sprintf("%v %v %q", c.field, c.operator, c.value)
+	}
+
+	return fmt.Sprintf("%T", c)
+}
+
+func TestParsePrecedence(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{
+			`if title = a then star`,
+			`title = "a"`,
+		},
+		{
+			`if title = a and url = b or feed = c then star`,
+			`((title = "a" and url = "b") or feed = "c")`,
+		},
+		{
+			`if title = a or url = b and feed = c then star`,
+			`(title = "a" or (url = "b" and feed = "c"))`,
+		},
+		{
+			`if title = a or url = b or feed = c then star`,
+			`((title = "a" or url = "b") or feed = "c")`,
+		},
+		{
+			`if title = a and url = b and feed = c then star`,
+			`((title = "a" and url = "b") and feed = "c")`,
+		},
+		{
+			`if (title = a or url = b) and feed = c then star`,
+			`((title = "a" or url = "b") and feed = "c")`,
+		},
+		{
+			`if title = a and (url = b or feed = c) then star`,
+			`(title = "a" and (url = "b" or feed = "c"))`,
+		},
+		{
+			`if not title = a and url = b then star`,
+			`((not title = "a") and url = "b")`,
+		},
+		{
+			`if not (title = a and url = b) then star`,
+			`(not (title = "a" and url = "b"))`,
+		},
+		{
+			`if not not title = a or url = b then star`,
+			`((not (not title = "a")) or url = "b")`,
+		},
+		{
+			`if ((title = a)) then star`,
+			`title = "a"`,
+		},
+		{
+			`IF Title CONTAINS "Go" AND NOT Category = Release THEN star`,
+			`(title contains "Go" and (not category = "release"))`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			rule, err := Parse(tt.src)
+			if err != nil {
+				t.Fatalf("Parse(%q) failed: %v", tt.src, err)
+			}
+			if got := describe(rule.condition); got != tt.want {
+				t.Errorf("Parse(%q) = %v, want %v", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseActions(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []Action
+	}{
+		{`if title = a then star`, []Action{{Kind: ActionStar}}},
+		{`if title = a then markread`, []Action{{Kind: ActionMarkRead}}},
+		{`if title = a then tag go`, []Action{{Kind: ActionTag, Tag: "go"}}},
+		{`if title = a then tag "Go Security"`, []Action{{Kind: ActionTag, Tag: "go security"}}},
+		{`if title = a then tag " Go "`, []Action{{Kind: ActionTag, Tag: "go"}}},
+		{
+			`if title = a then tag sec, star, markread`,
+			[]Action{{Kind: ActionTag, Tag: "sec"}, {Kind: ActionStar}, {Kind: ActionMarkRead}},
+		},
+	}
+
+	for _, tt := range tests {
+		rule, err := Parse(tt.src)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", tt.src, err)
+			continue
+		}
+		if !slices.Equal(rule.Actions, tt.want) {
+			t.Errorf("Parse(%q) actions = %v, want %v", tt.src, rule.Actions, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{``, `expected "if" at position 0, got end of rule`},
+		{`when title = a then star`, `expected "if" at position 0, got "when"`},
+		{`if title = a`, `expected "then" at position 12, got end of rule`},
+		{`if title = a star`, `expected "then" at position 13, got "star"`},
+		{`if then star`, `expected a field`},
+		{`if body = a then star`, `at position 3, got "body"`},
+		{`if title a then star`, `expected an operator (=, !=, contains, ~, !~) at position 9, got "a"`},
+		{`if title = then star`, `expected "then" at position 16, got "star"`},
+		{`if title = , then star`, `expected a string at position 11, got ","`},
+		{`if title ~ a then star`, `expected a regular expression at position 11, got "a"`},
+		{`if title ~ /a/g then star`, `unsupported regular expression flag 'g' at position 11`},
+		{`if title ~ /(/ then star`, `invalid regular expression at position 11`},
+		{`if (title = a then star`, `expected ")" at position 14, got "then"`},
+		{`if title = a or then star`, `expected a field`},
+		{`if title = a and not then star`, `at position 21, got "then"`},
+		{`if title = a then`, `expected an action (tag, star, markread) at position 17, got end of rule`},
+		{`if title = a then delete`, `unknown action "delete" at position 18`},
+		{`if title = a then tag`, `expected a tag name at position 21, got end of rule`},
+		{`if title = a then tag ""`, `expected a tag name at position 22`},
+		{`if title = a then tag "  "`, `expected a tag name at position 22`},
+		{`if title = a then star,`, `expected an action (tag, star, markread) at position 23`},
+		{`if title = a then star star`, `unexpected "star" at position 23`},
+		{`if title = a then star)`, `unexpected ")" at position 22`},
+		{`if title = "a then star`, `unterminated literal starting at position 11`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			_, err := Parse(tt.src)
+			if err == nil {
+				t.Fatalf("Parse(%q) succeeded, want error %q", tt.src, tt.want)
+			}
+			if !strings.HasPrefix(err.Error(), "invalid rule: ") {
+				t.Errorf("Parse(%q) error = %q, want an \"invalid rule\" error", tt.src, err)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse(%q) error = %q, want %q", tt.src, err, tt.want)
+			}
+		})
+	}
+}
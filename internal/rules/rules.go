@@ -0,0 +1,160 @@
+// Package rules implements the small declarative rule language used to tag,
+// star or mark posts as read when they are aggregated, e.g.
+//
+//	if feed = "Go Blog" and title ~ /security/i then tag sec, star
+//
+// Conditions compare a post field with a string or regular expression and can
+// be combined with "and", "or", "not" and parentheses.
+//
+// Fields:    feed (name or URL), url, title, description, content, author, category
+// Operators: = and != (case-insensitive equality), contains, ~ and !~ (regex)
+// Actions:   tag <name>, star, markread
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var fields = []string{"feed", "url", "title", "description", "content", "author", "category"}
+
+// Post is the subset of a post (and its feed) rules can match against.
+type Post struct {
+	FeedName    string
+	FeedURL     string
+	URL         string
+	Title       string
+	Description string
+	Content     string
+	Author      string
+	Categories  []string
+}
+
+// values returns every value of the field, most fields have exactly one but a
+// feed matches on both its name and URL and a post can have many categories.
+func (p Post) values(field string) []string {
+	switch field {
+	case "feed":
+		return []string{p.FeedName, p.FeedURL}
+	case "url":
+		return []string{p.URL}
+	case "title":
+		return []string{p.Title}
+	case "description":
+		return []string{p.Description}
+	case "content":
+		return []string{p.Content}
+	case "author":
+		return []string{p.Author}
+	case "category":
+		return p.Categories
+	}
+
+	return nil
+}
+
+type ActionKind string
+
+const (
+	ActionTag      ActionKind = "tag"
+	ActionStar     ActionKind = "star"
+	ActionMarkRead ActionKind = "markread"
+)
+
+type Action struct {
+	Kind ActionKind
+	// Tag is only set for ActionTag
+	Tag string
+}
+
+// NormalizeTag is the form tags are stored and compared in: trimmed and
+// lower-cased, so "Go" and "go " are the same tag.
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+func (a Action) String() string {
+	if a.Kind == ActionTag {
+		return fmt.Sprintf("%v %v", a.Kind, a.Tag)
+	}
+
+	return string(a.Kind)
+}
+
+// Rule is a parsed rule, ready to be evaluated against posts.
+type Rule struct {
+	condition condition
+	Actions   []Action
+}
+
+// Match reports whether the post satisfies the condition of the rule.
+func (r *Rule) Match(post Post) bool {
+	return r.condition.match(post)
+}
+
+type condition interface {
+	match(post Post) bool
+}
+
+type andCondition struct {
+	left, right condition
+}
+
+func (c andCondition) match(post Post) bool {
+	return c.left.match(post) && c.right.match(post)
+}
+
+type orCondition struct {
+	left, right condition
+}
+
+func (c orCondition) match(post Post) bool {
+	return c.left.match(post) || c.right.match(post)
+}
+
+type notCondition struct {
+	inner condition
+}
+
+func (c notCondition) match(post Post) bool {
+	return !c.inner.match(post)
+}
+
+type comparison struct {
+	field    string
+	operator string
+	value    string
+	pattern  *regexp.Regexp
+}
+
+// match is true when any of the field values satisfies the comparison. The
+// negated operators are true only when none of the values match, so
+// "category != release" means the post has no "release" category.
+func (c comparison) match(post Post) bool {
+	values := post.values(c.field)
+
+	switch c.operator {
+	case "=":
+		return slices.ContainsFunc(values, c.equals)
+	case "!=":
+		return !slices.ContainsFunc(values, c.equals)
+	case "contains":
+		return slices.ContainsFunc(values, c.contains)
+	case "~":
+		return slices.ContainsFunc(values, c.pattern.MatchString)
+	case "!~":
+		return !slices.ContainsFunc(values, c.pattern.MatchString)
+	}
+
+	return false
+}
+
+func (c comparison) equals(value string) bool {
+	return strings.EqualFold(value, c.value)
+}
+
+func (c comparison) contains(value string) bool {
+	return strings.Contains(strings.ToLower(value), strings.ToLower(c.value))
+}
@@ -0,0 +1,82 @@
+package rules
+
+import "testing"
+
+func TestRuleMatch(t *testing.T) {
+	goPost := Post{
+		FeedName:    "The Go Blog",
+		FeedURL:     "https://go.dev/blog/feed.atom",
+		URL:         "https://go.dev/blog/security-fix",
+		Title:       "Go 1.23.2 Security Release",
+		Description: "Fixes for net/http and crypto/tls",
+		Content:     "<p>We have just released Go 1.23.2.</p>",
+		Author:      "Go Team",
+		Categories:  []string{"release", "security"},
+	}
+	hnPost := Post{
+		FeedName:   "Hacker News",
+		FeedURL:    "https://news.ycombinator.com/rss",
+		URL:        "https://example.com/postgres-tips",
+		Title:      "Ten Postgres tips",
+		Author:     "",
+		Categories: nil,
+	}
+
+	tests := []struct {
+		rule string
+		post Post
+		want bool
+	}{
+		// equality is case-insensitive and feed matches on name or URL
+		{`if feed = "the go blog" then star`, goPost, true},
+		{`if feed = "https://go.dev/blog/feed.atom" then star`, goPost, true},
+		{`if feed = "Go Blog" then star`, goPost, false},
+		{`if feed != "Hacker News" then star`, goPost, true},
+		{`if feed != "Hacker News" then star`, hnPost, false},
+		{`if author = "" then star`, hnPost, true},
+
+		// contains is case-insensitive
+		{`if title contains "security" then star`, goPost, true},
+		{`if description contains "CRYPTO/TLS" then star`, goPost, true},
+		{`if content contains "<p>" then star`, goPost, true},
+		{`if url contains "postgres" then star`, goPost, false},
+
+		// regular expressions are case-sensitive unless flagged
+		{`if title ~ /security/ then star`, goPost, false},
+		{`if title ~ /security/i then star`, goPost, true},
+		{`if title ~ "^Go \d+\.\d+" then star`, goPost, true},
+		{`if url !~ /^https:\/\/go\.dev\// then star`, goPost, false},
+		{`if url !~ /^https:\/\/go\.dev\// then star`, hnPost, true},
+
+		// categories match when any value matches, negations when none do
+		{`if category = security then star`, goPost, true},
+		{`if category = Release then star`, goPost, true},
+		{`if category != release then star`, goPost, false},
+		{`if category != release then star`, hnPost, true},
+		{`if category = release then star`, hnPost, false},
+		{`if category ~ /^sec/ then star`, goPost, true},
+		{`if category !~ /^sec/ then star`, hnPost, true},
+
+		// combinations
+		{`if feed = "The Go Blog" and title ~ /security/i then tag sec`, goPost, true},
+		{`if feed = "The Go Blog" and title ~ /security/i then tag sec`, hnPost, false},
+		{`if title contains postgres or title contains security then star`, hnPost, true},
+		{`if title contains postgres or title contains security then star`, goPost, true},
+		{`if not feed = "Hacker News" then star`, hnPost, false},
+		{`if not feed = "Hacker News" then star`, goPost, true},
+		{`if feed = "Hacker News" or feed = "The Go Blog" and category = release then star`, hnPost, true},
+		{`if (feed = "Hacker News" or feed = "The Go Blog") and category = release then star`, hnPost, false},
+		{`if not (author = "" or category = release) then star`, goPost, false},
+	}
+
+	for _, tt := range tests {
+		rule, err := Parse(tt.rule)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", tt.rule, err)
+			continue
+		}
+		if got := rule.Match(tt.post); got != tt.want {
+			t.Errorf("%q matching %q = %v, want %v", tt.rule, tt.post.Title, got, tt.want)
+		}
+	}
+}
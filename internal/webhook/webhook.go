@@ -0,0 +1,294 @@
+// Package webhook builds the JSON payloads of gator webhooks and delivers
+// them with signing and retries.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Payload formats. Slack and Mattermost incoming webhooks accept the same
+// "text" payload, Discord expects "content" and embeds, and the generic
+// format carries the whole event.
+const (
+	FormatGeneric    = "generic"
+	FormatSlack      = "slack"
+	FormatDiscord    = "discord"
+	FormatMattermost = "mattermost"
+)
+
+// Formats lists the supported payload formats.
+var Formats = []string{FormatGeneric, FormatSlack, FormatDiscord, FormatMattermost}
+
+// Events sent to webhooks.
+const (
+	EventPostCreated = "post.created"
+	EventTest        = "webhook.test"
+)
+
+// Headers of every delivery. The signature is only sent when the webhook has
+// a secret.
+const (
+	HeaderEvent     = "X-Gator-Event"
+	HeaderTimestamp = "X-Gator-Timestamp"
+	HeaderSignature = "X-Gator-Signature"
+)
+
+// Post is the post an event is about.
+type Post struct {
+	ID          string     `json:"id"`
+	FeedID      string     `json:"feed_id"`
+	FeedName    string     `json:"feed_name"`
+	FeedURL     string     `json:"feed_url"`
+	Title       string     `json:"title"`
+	URL         string     `json:"url"`
+	Author      string     `json:"author,omitempty"`
+	Summary     string     `json:"summary,omitempty"`
+	Tags        []string   `json:"tags"`
+	PublishedAt *time.Time `json:"published_at"`
+}
+
+// Event is the payload of the generic format.
+type Event struct {
+	Event   string    `json:"event"`
+	Webhook string    `json:"webhook"`
+	User    string    `json:"user"`
+	SentAt  time.Time `json:"sent_at"`
+	Post    Post      `json:"post"`
+}
+
+type textPayload struct {
+	Text string `json:"text"`
+}
+
+type discordPayload struct {
+	Content string         `json:"content"`
+	Embeds  []discordEmbed `json:"embeds,omitempty"`
+}
+
+type discordEmbed struct {
+	Title       string         `json:"title"`
+	URL         string         `json:"url,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Author      *discordAuthor `json:"author,omitempty"`
+	Timestamp   string         `json:"timestamp,omitempty"`
+}
+
+type discordAuthor struct {
+	Name string `json:"name"`
+}
+
+// Discord rejects embeds with longer descriptions
+const discordDescriptionLimit = 4096
+
+// Body encodes the event in the given format.
+func Body(format string, event Event) ([]byte, error) {
+	switch format {
+	case FormatGeneric:
+		return json.Marshal(event)
+	case FormatSlack:
+		return json.Marshal(textPayload{Text: slackText(event)})
+	case FormatMattermost:
+		return json.Marshal(textPayload{Text: markdownText(event)})
+	case FormatDiscord:
+		return json.Marshal(discordBody(event))
+	}
+
+	return nil, fmt.Errorf("unknown webhook format %q", format)
+}
+
+func headline(event Event) string {
+	if event.Event == EventTest {
+		return fmt.Sprintf("Test of the gator webhook %q", event.Webhook)
+	}
+
+	return fmt.Sprintf("New post in %v", event.Post.FeedName)
+}
+
+// slackText uses the mrkdwn syntax of Slack, where links are <url|text> and
+// &, < and > must be escaped.
+func slackText(event Event) string {
+	escape := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace
+
+	lines := []string{escape(headline(event))}
+	if event.Post.URL != "" {
+		lines = append(lines, fmt.Sprintf("*<%v|%v>*", event.Post.URL, escape(event.Post.Title)))
+	} else {
+		lines = append(lines, "*"+escape(event.Post.Title)+"*")
+	}
+	if event.Post.Summary != "" {
+		lines = append(lines, escape(event.Post.Summary))
+	}
+	if len(event.Post.Tags) > 0 {
+		lines = append(lines, "Tags: "+escape(strings.Join(event.Post.Tags, ", ")))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func markdownText(event Event) string {
+	lines := []string{headline(event)}
+	if event.Post.URL != "" {
+		lines = append(lines, fmt.Sprintf("**[%v](%v)**", event.Post.Title, event.Post.URL))
+	} else {
+		lines = append(lines, "**"+event.Post.Title+"**")
+	}
+	if event.Post.Summary != "" {
+		lines = append(lines, event.Post.Summary)
+	}
+	if len(event.Post.Tags) > 0 {
+		lines = append(lines, "Tags: "+strings.Join(event.Post.Tags, ", "))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func discordBody(event Event) discordPayload {
+	embed := discordEmbed{
+		Title:       event.Post.Title,
+		URL:         event.Post.URL,
+		Description: event.Post.Summary,
+	}
+	if runes := []rune(embed.Description); len(runes) > discordDescriptionLimit {
+		embed.Description = string(runes[:discordDescriptionLimit-1]) + "…"
+	}
+	if event.Post.Author != "" {
+		embed.Author = &discordAuthor{Name: event.Post.Author}
+	}
+	if event.Post.PublishedAt != nil {
+		embed.Timestamp = event.Post.PublishedAt.Format(time.RFC3339)
+	}
+
+	return discordPayload{
+		Content: headline(event),
+		Embeds:  []discordEmbed{embed},
+	}
+}
+
+// Sign returns the signature of a delivery, the hex encoded HMAC-SHA256 of
+// "<timestamp>.<body>" keyed with the secret. Receivers recompute it from the
+// X-Gator-Timestamp header and the raw body, and should reject old timestamps
+// to prevent replays.
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Result describes a delivery after the last attempt. StatusCode is 0 when no
+// response was received.
+type Result struct {
+	Attempts   int
+	StatusCode int
+	Err        error
+}
+
+// Sender delivers payloads, retrying network errors, 429 and 5xx responses
+// with exponential backoff. Other responses are final.
+type Sender struct {
+	Client      *http.Client
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+}
+
+// NewSender returns a sender with the defaults of gator: 10 seconds per
+// request and 4 attempts, 2, 4 and 8 seconds apart.
+func NewSender() *Sender {
+	return &Sender{
+		Client:      &http.Client{Timeout: 10 * time.Second},
+		MaxAttempts: 4,
+		Backoff:     2 * time.Second,
+		MaxBackoff:  time.Minute,
+	}
+}
+
+// Send posts the body to the URL until it is accepted, the attempts run out
+// or the context is done.
+func (s *Sender) Send(ctx context.Context, url string, secret string, event string, body []byte) Result {
+	result := Result{}
+	backoff := s.Backoff
+
+	for result.Attempts < s.MaxAttempts {
+		result.Attempts++
+
+		var retryAfter time.Duration
+		var retry bool
+		result.StatusCode, retryAfter, retry, result.Err = s.attempt(ctx, url, secret, event, body)
+		if !retry || result.Attempts == s.MaxAttempts {
+			break
+		}
+
+		wait := max(backoff, retryAfter)
+		if s.MaxBackoff > 0 {
+			wait = min(wait, s.MaxBackoff)
+		}
+		backoff *= 2
+
+		select {
+		case <-ctx.Done():
+			result.Err = ctx.Err()
+			return result
+		case <-time.After(wait):
+		}
+	}
+
+	return result
+}
+
+// attempt makes a single request and reports whether it should be retried
+// and how long the receiver asked to wait.
+func (s *Sender) attempt(ctx context.Context, url string, secret string, event string, body []byte) (int, time.Duration, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, 0, false, err
+	}
+
+	// Signed per attempt so receivers can reject stale timestamps
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gator")
+	req.Header.Set(HeaderEvent, event)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	if secret != "" {
+		req.Header.Set(HeaderSignature, Sign(secret, timestamp, body))
+	}
+
+	res, err := s.Client.Do(req)
+	if err != nil {
+		return 0, 0, ctx.Err() == nil, err
+	}
+	defer res.Body.Close()
+
+	// Drain the body so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64*1024))
+
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return res.StatusCode, 0, false, nil
+	}
+
+	err = fmt.Errorf("unexpected status: %v", res.Status)
+	if res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500 {
+		retryAfter := time.Duration(0)
+		if seconds, parseErr := strconv.Atoi(res.Header.Get("Retry-After")); parseErr == nil && seconds > 0 {
+			retryAfter = time.Duration(seconds) * time.Second
+		}
+
+		return res.StatusCode, retryAfter, true, err
+	}
+
+	return res.StatusCode, 0, false, err
+}
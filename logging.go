@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/config"
+)
+
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+
+	logFormatEnv = "GATOR_LOG_FORMAT"
+	logLevelEnv  = "GATOR_LOG_LEVEL"
+)
+
+// newLogger creates a logger writing records of the level and above in the
+// format, "text" (logfmt style key=value pairs) or "json" (one object per
+// line). Empty values select text and info.
+func newLogger(w io.Writer, format string, level string) (*slog.Logger, error) {
+	var minLevel slog.Level
+	if level != "" {
+		if err := minLevel.UnmarshalText([]byte(level)); err != nil {
+			return nil, fmt.Errorf("invalid log level %q, valid levels are debug, info, warn and error", level)
+		}
+	}
+
+	opts := &slog.HandlerOptions{Level: minLevel}
+
+	switch format {
+	case logFormatText, "":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case logFormatJSON:
+		opts.ReplaceAttr = durationSeconds
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	}
+
+	return nil, fmt.Errorf("invalid log format %q, valid formats are %q and %q", format, logFormatText, logFormatJSON)
+}
+
+// durationSeconds writes durations as seconds, JSON would get nanoseconds.
+func durationSeconds(groups []string, attr slog.Attr) slog.Attr {
+	if attr.Value.Kind() == slog.KindDuration {
+		return slog.Float64(attr.Key, attr.Value.Duration().Seconds())
+	}
+
+	return attr
+}
+
+// setupLogging makes the logger configured by the environment or, when the
+// variables are not set, by the config file the default logger. Logs go to
+// stderr so they do not mix with the output of commands. It returns the
+// format in use.
+func setupLogging(cfg *config.Config) (string, error) {
+	format := cfg.LogFormat
+	if value, ok := os.LookupEnv(logFormatEnv); ok {
+		format = value
+	}
+
+	level := cfg.LogLevel
+	if value, ok := os.LookupEnv(logLevelEnv); ok {
+		level = value
+	}
+
+	logger, err := newLogger(os.Stderr, format, level)
+	if err != nil {
+		return "", err
+	}
+
+	slog.SetDefault(logger)
+
+	if format == "" {
+		format = logFormatText
+	}
+
+	return format, nil
+}
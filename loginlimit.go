@@ -0,0 +1,114 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Failed web logins are limited per user name and per client address. Every
+// attempt runs a full argon2id hash, so the limits also bound the CPU a single
+// client can use for guessing.
+const (
+	loginFailureWindow  = 15 * time.Minute
+	loginFailuresByUser = 5
+	loginFailuresByIP   = 20
+)
+
+// loginLimiter remembers the failed logins of the last window. It lives in
+// memory, so restarting "gator serve" clears it.
+type loginLimiter struct {
+	mu        sync.Mutex
+	failures  map[string][]time.Time
+	lastPrune time.Time
+	now       func() time.Time
+}
+
+func newLoginLimiter() *loginLimiter {
+	return &loginLimiter{
+		failures: make(map[string][]time.Time),
+		now:      time.Now,
+	}
+}
+
+// loginKeys returns the keys a login attempt is counted under, the address
+// key first.
+func loginKeys(r *http.Request, userName string) (string, string) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	return "ip:" + host, "user:" + strings.ToLower(userName)
+}
+
+// retryAfter returns how long the client has to wait before the next attempt,
+// zero when it may try now.
+func (limiter *loginLimiter) retryAfter(ipKey string, userKey string) time.Duration {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	now := limiter.now()
+	wait := limiter.wait(ipKey, loginFailuresByIP, now)
+	if userWait := limiter.wait(userKey, loginFailuresByUser, now); userWait > wait {
+		wait = userWait
+	}
+
+	return wait
+}
+
+// wait returns the time until the oldest failure of the key leaves the window
+// once the key reached its limit.
+func (limiter *loginLimiter) wait(key string, limit int, now time.Time) time.Duration {
+	recent := limiter.recent(key, now)
+	if len(recent) < limit {
+		return 0
+	}
+
+	return recent[len(recent)-limit].Add(loginFailureWindow).Sub(now)
+}
+
+// recent drops the failures of the key which left the window.
+func (limiter *loginLimiter) recent(key string, now time.Time) []time.Time {
+	failures := limiter.failures[key]
+	for len(failures) > 0 && !failures[0].Add(loginFailureWindow).After(now) {
+		failures = failures[1:]
+	}
+
+	if len(failures) == 0 {
+		delete(limiter.failures, key)
+		return nil
+	}
+	limiter.failures[key] = failures
+
+	return failures
+}
+
+func (limiter *loginLimiter) failed(ipKey string, userKey string) {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	now := limiter.now()
+	for _, key := range []string{ipKey, userKey} {
+		limiter.failures[key] = append(limiter.recent(key, now), now)
+	}
+
+	// Keys which are not tried again would stay forever otherwise
+	if now.Sub(limiter.lastPrune) > loginFailureWindow {
+		for key := range limiter.failures {
+			limiter.recent(key, now)
+		}
+		limiter.lastPrune = now
+	}
+}
+
+// succeeded forgets the failures of the user, the address keeps its count so
+// logging in to one account does not reset guessing at the others.
+func (limiter *loginLimiter) succeeded(userKey string) {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	delete(limiter.failures, userKey)
+}
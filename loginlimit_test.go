@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestLoginLimiter(now *time.Time) *loginLimiter {
+	limiter := newLoginLimiter()
+	limiter.now = func() time.Time { return *now }
+	return limiter
+}
+
+func TestLoginLimiterByUser(t *testing.T) {
+	now := time.Date(2024, 10, 1, 8, 0, 0, 0, time.UTC)
+	limiter := newTestLoginLimiter(&now)
+
+	for i := range loginFailuresByUser {
+		if wait := limiter.retryAfter("ip:192.0.2.1", "user:alice"); wait != 0 {
+			t.Fatalf("attempt %v must wait %v, want 0", i+1, wait)
+		}
+		limiter.failed("ip:192.0.2.1", "user:alice")
+		now = now.Add(time.Minute)
+	}
+
+	// The first failure leaves the window 15 minutes after it happened
+	wait := limiter.retryAfter("ip:198.51.100.1", "user:alice")
+	if want := loginFailureWindow - loginFailuresByUser*time.Minute; wait != want {
+		t.Errorf("retryAfter = %v, want %v", wait, want)
+	}
+	if wait := limiter.retryAfter("ip:192.0.2.1", "user:bob"); wait != 0 {
+		t.Errorf("other user must wait %v, want 0", wait)
+	}
+
+	now = now.Add(wait)
+	if wait := limiter.retryAfter("ip:192.0.2.1", "user:alice"); wait != 0 {
+		t.Errorf("retryAfter after the window = %v, want 0", wait)
+	}
+}
+
+func TestLoginLimiterByIP(t *testing.T) {
+	now := time.Date(2024, 10, 1, 8, 0, 0, 0, time.UTC)
+	limiter := newTestLoginLimiter(&now)
+
+	for i := range loginFailuresByIP {
+		limiter.failed("ip:192.0.2.1", fmt.Sprintf("user:guess%v", i))
+	}
+
+	if wait := limiter.retryAfter("ip:192.0.2.1", "user:someone"); wait != loginFailureWindow {
+		t.Errorf("retryAfter = %v, want %v", wait, loginFailureWindow)
+	}
+	if wait := limiter.retryAfter("ip:198.51.100.1", "user:someone"); wait != 0 {
+		t.Errorf("other address must wait %v, want 0", wait)
+	}
+}
+
+func TestLoginLimiterSucceeded(t *testing.T) {
+	now := time.Date(2024, 10, 1, 8, 0, 0, 0, time.UTC)
+	limiter := newTestLoginLimiter(&now)
+
+	for range loginFailuresByUser - 1 {
+		limiter.failed("ip:192.0.2.1", "user:alice")
+	}
+	limiter.succeeded("user:alice")
+	limiter.failed("ip:192.0.2.1", "user:alice")
+
+	if wait := limiter.retryAfter("ip:192.0.2.1", "user:alice"); wait != 0 {
+		t.Errorf("retryAfter after a successful login = %v, want 0", wait)
+	}
+}
+
+func TestLoginLimiterPrunes(t *testing.T) {
+	now := time.Date(2024, 10, 1, 8, 0, 0, 0, time.UTC)
+	limiter := newTestLoginLimiter(&now)
+
+	limiter.failed("ip:192.0.2.1", "user:alice")
+	now = now.Add(loginFailureWindow + time.Second)
+	limiter.failed("ip:198.51.100.1", "user:bob")
+
+	if len(limiter.failures) != 2 {
+		t.Errorf("failures = %v, want only the keys of the last attempt", limiter.failures)
+	}
+}
+
+func TestLoginKeys(t *testing.T) {
+	r := httptest.NewRequest("POST", "/login", nil)
+	r.RemoteAddr = "192.0.2.1:51234"
+
+	ipKey, userKey := loginKeys(r, "Alice")
+	if ipKey != "ip:192.0.2.1" || userKey != "user:alice" {
+		t.Errorf("loginKeys = %q, %q, want the address without port and the lowercased name", ipKey, userKey)
+	}
+}
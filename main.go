@@ -8,12 +8,14 @@ import (
 	"fmt"
 	"html"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
-	"strconv"
+	"os/signal"
+	"syscall"
 	"time"
 
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/auth"
 	"github.com/fekete965/boot.dev-blog-aggregator/internal/config"
 	"github.com/fekete965/boot.dev-blog-aggregator/internal/database"
 	"github.com/google/uuid"
@@ -24,6 +26,9 @@ import (
 type state struct {
 	config *config.Config
 	database *database.Queries 
+	db *sql.DB
+	// Set while aggregating to deliver webhooks in the background
+	webhooks *webhookQueue
 }
 
 type command struct {
@@ -86,7 +91,11 @@ func handleLogin(s *state, cmd command) error {
 		return fmt.Errorf("failed to login: %v", err)
 	}
 
-	if err := s.config.SetUser(existingUser.Name); err != nil {
+	if err := verifyPassword(existingUser, "Password: "); err != nil {
+		return err
+	}
+
+	if err := startSession(s, existingUser); err != nil {
 		return fmt.Errorf("an error occurred during login: %v", err)
 	}
 
@@ -94,12 +103,40 @@ func handleLogin(s *state, cmd command) error {
 	return nil
 }
 
-func registerUser(s *state, username string) (database.User, error) {
-	newUser, err := s.database.CreateUser(context.Background(), database.CreateUserParams{
+// registerUser creates a user with the hash of its password (empty for no
+// password), the first user of a database becomes its administrator. The users table is locked until the user is created, so two
+// users registering at once (or a user deleted meanwhile) cannot both or
+// neither become the administrator.
+func registerUser(s *state, username string, passwordHash string) (database.User, error) {
+	tx, err := s.db.BeginTx(context.Background(), nil)
+	if err != nil {
+		return database.User{}, fmt.Errorf("failed to start transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	queries := s.database.WithTx(tx)
+
+	if err := queries.LockUsers(context.Background()); err != nil {
+		return database.User{}, fmt.Errorf("failed to lock users: %v", err)
+	}
+
+	userCount, err := queries.CountUsers(context.Background())
+	if err != nil {
+		return database.User{}, fmt.Errorf("failed to count users: %v", err)
+	}
+
+	role := roleUser
+	if userCount == 0 {
+		role = roleAdmin
+	}
+
+	newUser, err := queries.CreateUser(context.Background(), database.CreateUserParams{
 		ID: uuid.New(),
 		Name: username,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
+		PasswordHash: passwordHash,
+		Role: role,
 	})
 	if err != nil {
 		var pqErr *pq.Error
@@ -111,6 +148,10 @@ func registerUser(s *state, username string) (database.User, error) {
 		return database.User{}, fmt.Errorf("failed to register user: %v", err)
 	}
 
+	if err := tx.Commit(); err != nil {
+		return database.User{}, fmt.Errorf("failed to register user: %v", err)
+	}
+
 	return newUser, nil
 }
 
@@ -121,7 +162,32 @@ func handleRegister(s *state, cmd command) error {
 
 	username := cmd.args[0]
 
-	createdUser, err := registerUser(s, username)
+	// Checked before asking for a password, creating the user still fails if
+	// someone takes the name in the meantime
+	_, err := s.database.FindUserByeName(context.Background(), username)
+	if err == nil {
+		return fmt.Errorf("user already exists. Please use a different username")
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to register user: %v", err)
+	}
+
+	password, err := readNewPassword("Password (leave empty for no password): ")
+	if err != nil {
+		return err
+	}
+
+	// The user is created with its password, a failure cannot leave behind an
+	// account anyone can log into
+	passwordHash := ""
+	if password != "" {
+		passwordHash, err = auth.HashPassword(password)
+		if err != nil {
+			return fmt.Errorf("failed to hash password: %v", err)
+		}
+	}
+
+	createdUser, err := registerUser(s, username, passwordHash)
 	if err != nil {
 		if err == ErrUserAlreadyExists {
 			return fmt.Errorf("user already exists. Please use a different username")
@@ -130,23 +196,18 @@ func handleRegister(s *state, cmd command) error {
 		return fmt.Errorf("failed to register user: %v", err)
 	}
 
-	if err := s.config.SetUser(createdUser.Name); err != nil {
+	if err := startSession(s, createdUser); err != nil {
 		return fmt.Errorf("an error occurred during registration: %v", err)
 	}
 
 	fmt.Printf("New user registered: %v\n", username)
-
-	return nil
-}
-
-func handleReset(s *state, cmd command) error {
-	err := s.database.DeleteAllUsers(context.Background())
-	if err != nil {
-		return fmt.Errorf("failed to reset database: %v", err)
+	if createdUser.Role == roleAdmin {
+		fmt.Printf("%v is the administrator of this database\n", username)
+		if password == "" {
+			fmt.Println("Set a password with gator passwd to use the administrator commands")
+		}
 	}
 
-	fmt.Println("Database has been reset")
-
 	return nil
 }
 
@@ -162,6 +223,10 @@ func handleUsers(s *state, cmd command) error {
 		if *s.config.CurrentUserName == user.Name {
 			outputName += " (current)"
 		}
+
+		if user.Role == roleAdmin {
+			outputName += " (admin)"
+		}
 		
 		fmt.Printf("* %v\n", outputName)
 	}
@@ -179,19 +244,36 @@ func handleAggregate(s *state, cmd command) error {
 		return fmt.Errorf("failed to parse time between requests: %v", err)
 	}
 
-	fmt.Printf("Collecting feeds every %v\n", timeBetweenReqs)
+	slog.Info("aggregator started", "interval", timeBetweenReqs)
+
+	// Ctrl-C stops the aggregator after the current feed, the queued webhook
+	// deliveries are still sent and logged. A second Ctrl-C exits at once.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	ticker := time.NewTicker(timeBetweenReqs)
+	defer ticker.Stop()
 
-	for ; ; <- ticker.C {
+	s.webhooks = startWebhookQueue(s)
+	defer s.webhooks.close()
+
+	for {
 		err := scrapeFeeds(s)
 		if errors.Is(err, ErrNoNextFeedFound) {
-			fmt.Println("No feeds to scrape")
+			slog.Warn("no feeds to scrape, stopping the aggregator")
 			return nil
 		}
 		if err != nil {
 			return fmt.Errorf("error scraping feeds: %v", err)
 		}
+
+		select {
+		case <-ctx.Done():
+			stop()
+			slog.Info("aggregator stopped, waiting for webhook deliveries", "queued", len(s.webhooks.jobs))
+			return nil
+		case <-ticker.C:
+		}
 	}
 }
 
@@ -200,6 +282,20 @@ type RSSItem struct {
 	Link        string `xml:"link"`
 	Description string `xml:"description"`
 	PubDate     string `xml:"pubDate"`
+	Author      string `xml:"author"`
+	Creator     string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Content     string `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	Categories  []string `xml:"category"`
+}
+
+// AuthorName returns the author of the item, preferring the Dublin Core
+// creator which usually holds a name rather than an e-mail address.
+func (item RSSItem) AuthorName() string {
+	if item.Creator != "" {
+		return item.Creator
+	}
+
+	return item.Author
 }
 
 type RSSChannel struct {
@@ -213,10 +309,12 @@ type RSSFeed struct {
 	Channel RSSChannel `xml:"channel"`
 }
 
-func fetchFeed(ctx context.Context, feedUrl string) (*RSSFeed, error) {
+// fetchFeed downloads and parses the feed. The HTTP status is returned for
+// logging, it is 0 when no response was received.
+func fetchFeed(ctx context.Context, feedUrl string) (*RSSFeed, int, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", feedUrl, nil)
 	if err != nil {
-		return nil, fmt.Errorf("something went wrong creating the request: %v", err)
+		return nil, 0, fmt.Errorf("something went wrong creating the request: %v", err)
 	}
 
 	req.Header.Set("User-Agent", "gator/1.0")
@@ -224,25 +322,25 @@ func fetchFeed(ctx context.Context, feedUrl string) (*RSSFeed, error) {
 	client := http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("something went wrong fetching the feed: %v", err)
+		return nil, 0, fmt.Errorf("something went wrong fetching the feed: %v", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("something went wrong reading the response body: %v", err)
+		return nil, resp.StatusCode, fmt.Errorf("something went wrong reading the response body: %v", err)
 	}
 	
 	if resp.StatusCode > 299 {
-		return nil, fmt.Errorf("response failed with status code: %v and body %v", resp.StatusCode, body)
+		return nil, resp.StatusCode, fmt.Errorf("response failed with status code: %v and body %v", resp.StatusCode, body)
 	}
 
 	var result RSSFeed = RSSFeed{}
 	if err := xml.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("body marshalling failed: %v", err)
+		return nil, resp.StatusCode, fmt.Errorf("body marshalling failed: %v", err)
 	}
 
-	return &result, nil
+	return &result, resp.StatusCode, nil
 }
 
 func handleAddFeed(s *state, cmd command, user database.User) error {
@@ -348,8 +446,20 @@ func handleFollowing(s *state, cmd command, user database.User) error {
 	}
 	fmt.Printf("Current user is following %v %v\n", feedLength, postfix)
 
-	for _, feedFollow := range feedFollows {
-		fmt.Printf("- %v", feedFollow.FeedName)
+	if err := printFeedFollowsByFolder(s, user, feedFollows); err != nil {
+		return err
+	}
+
+	savedSearches, err := s.database.GetSavedSearchesForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get saved searches for user: %v", err)
+	}
+
+	if len(savedSearches) > 0 {
+		fmt.Printf("Saved searches\n")
+		for _, savedSearch := range savedSearches {
+			fmt.Printf("- %v (%v unread)\n", savedSearch.Name, savedSearch.UnreadCount)
+		}
 	}
 
 	return nil
@@ -357,16 +467,12 @@ func handleFollowing(s *state, cmd command, user database.User) error {
 
 func middlewareLoggedIn(handler func(s *state, cmd command, user database.User) error) func(s *state, cmd command) error {
 	var responseFn = func(s *state, cmd command) error {
-			if s.config.CurrentUserName == nil || *s.config.CurrentUserName == "" {
-				return fmt.Errorf("you must be logged in to use this feature. Use: gator login <username> or gator register <username>")
-			}
-			
-			currentUser, err := s.database.FindUserByeName(context.Background(), *s.config.CurrentUserName)
+			user, err := currentUser(s)
 			if err != nil {
-				return fmt.Errorf("failed to find current user: %v", err)
+				return err
 			}
 
-			return handler(s, cmd, currentUser)
+			return handler(s, cmd, user)
 	}
 
 	return responseFn
@@ -390,6 +496,14 @@ func handleUnfollow(s *state, cmd command, user database.User) error {
 		return fmt.Errorf("failed to unfollow the feed: %v", err)
 	}
 
+	err = s.database.RemoveFeedFromUserFolders(context.Background(), database.RemoveFeedFromUserFoldersParams{
+		UserID: user.ID,
+		FeedID: feed.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove the feed from folders: %v", err)
+	}
+
 	fmt.Printf("Successfully unfollowed the feed: %v\n", feed.Name)
 
 	return nil
@@ -434,15 +548,31 @@ func scrapeFeeds(s *state) error {
 		return fmt.Errorf("failed to mark feed fetched: %v", err)
 	}
 
-	rssFeed, err := fetchFeed(context.Background(), nextFeed.Url)
+	logger := slog.With("feed_id", nextFeed.ID, "url", nextFeed.Url)
+	start := time.Now()
+
+	rssFeed, status, err := fetchFeed(context.Background(), nextFeed.Url)
 	if err != nil {
+		logger.Error("failed to scrape feed", "status", status, "duration", time.Since(start), "posts_new", 0, "posts_skipped", 0, "error", err)
 		return fmt.Errorf("failed to fetch feed: %v", err)
 	}
+
+	if siteUrl := html.UnescapeString(rssFeed.Channel.Link); siteUrl != "" && siteUrl != nextFeed.SiteUrl {
+		err = s.database.SetFeedSiteUrl(context.Background(), database.SetFeedSiteUrlParams{
+			SiteUrl: siteUrl,
+			ID: nextFeed.ID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update feed site URL: %v", err)
+		}
+	}
 	
+	newCount := 0
+	existingCount := 0
 	for _, item := range rssFeed.Channel.Item {
 		publishedAt, dateParsingErr := parsePubDate(item.PubDate)
 
-		err = createPost(s, database.CreatePostParams{
+		err = createPost(s, nextFeed, database.CreatePostParams{
 			ID: uuid.New(),
 			FeedID: nextFeed.ID,
 			Title: html.UnescapeString(item.Title),
@@ -454,22 +584,40 @@ func scrapeFeeds(s *state) error {
 			},
 			CreatedAt: time.Now(),
 			UpdatedAt: time.Now(),
+			Author: html.UnescapeString(item.AuthorName()),
+			Content: html.UnescapeString(item.Content),
+			Categories: unescapeStrings(item.Categories),
 		})
 
 		if err == ErrPostExists {
-			fmt.Printf("post already exists: %v\n", item.Title)
+			logger.Debug("post already stored", "post_url", item.Link)
+			existingCount++
 			continue
 		}
 
 		if err != nil {
+			logger.Error("failed to scrape feed", "status", status, "duration", time.Since(start), "posts_new", newCount, "posts_skipped", existingCount, "error", err)
 			return fmt.Errorf("failed to scrape post: %v", err)
 		}
+
+		newCount++
 	}
 
+	logger.Info("feed scraped", "feed_name", nextFeed.Name, "status", status, "duration", time.Since(start), "posts_new", newCount, "posts_skipped", existingCount)
+
 	return nil
 }
 
-func createPost(s *state, data database.CreatePostParams) error {
+func unescapeStrings(values []string) []string {
+	unescaped := make([]string, 0, len(values))
+	for _, value := range values {
+		unescaped = append(unescaped, html.UnescapeString(value))
+	}
+
+	return unescaped
+}
+
+func createPost(s *state, feed database.Feed, data database.CreatePostParams) error {
 	newPost, err := s.database.CreatePost(context.Background(), data)
 	if err != nil {
 		var pqErr *pq.Error
@@ -481,75 +629,46 @@ func createPost(s *state, data database.CreatePostParams) error {
 		return fmt.Errorf("failed to create post: %v", err)
 	}
 
-	fmt.Printf("Post successfully created: %v\n", newPost.Title)
-
-	return nil
-}
+	logger := slog.With("feed_id", feed.ID, "post_id", newPost.ID)
+	logger.Debug("post stored", "title", newPost.Title, "post_url", newPost.Url)
 
-func truncateString(str string, maxLength int) string {
-	if len(str) <= maxLength {
-		return str
+	if err := applyRules(s, feed, newPost); err != nil {
+		logger.Error("failed to apply rules", "error", err)
 	}
 
-	return str[:maxLength - 3] + "..."
-}
-
-func handleBrowse(s *state, cmd command, user database.User) error {
-	postLimitArg := "2"
-	if len(cmd.args) > 0 {
-		postLimitArg = cmd.args[0]
-	}
-
-	postLimit, err := strconv.Atoi(postLimitArg)
-	if err != nil {
-		fmt.Printf("failed to convert post limit to int: %v\n", err)
-		postLimit = 2
+	if err := notifySavedSearchMatches(s, newPost); err != nil {
+		logger.Error("failed to check saved searches", "error", err)
 	}
 
-	userPosts, err := s.database.GetPostForUser(context.Background(), database.GetPostForUserParams{
-		UserID: user.ID,
-		Limit: int32(postLimit),
-	})
-	if err != nil {
-		return fmt.Errorf("failed to get posts for the user: %v", err)
+	if err := notifyWebhooks(s, feed, newPost); err != nil {
+		logger.Error("failed to notify webhooks", "error", err)
 	}
 
-	if len(userPosts) == 0 {
-		fmt.Println("No posts found")
-		return nil
+	if err := notifyFollowers(s, feed, newPost); err != nil {
+		logger.Error("failed to send notifications", "error", err)
 	}
 
-	for _, post := range userPosts {
-		publishedAtStr := "N/A"
-		if post.PublishedAt.Valid {
-			publishedAtStr = post.PublishedAt.Time.Format("02 January 2006 15:04")
-		}
-
-		fmt.Println("┌─────────────────────────────────────────────────────────────┐")
-		fmt.Printf("│ Title: %-50s │\n", truncateString(post.Title, 50))
-		fmt.Printf("│ Published At: %-44s │\n", publishedAtStr)
-		fmt.Println("├─────────────────────────────────────────────────────────────┤")
-		fmt.Printf("│ Description: %-51s │\n", "")
-		fmt.Printf("│ %-59s │\n", truncateString(post.Description, 59))
-		fmt.Println("├─────────────────────────────────────────────────────────────┤")
-		fmt.Printf("│ URL: %-55s │\n", truncateString(post.Url, 55))
-		fmt.Println("└─────────────────────────────────────────────────────────────┘")
-		fmt.Println()
-	}
-	
 	return nil
 }
 
 func main() {
 	configFile, err := config.Read()
 	if err != nil {
-		log.Fatalf("failed to read config file: %v", err)
+		slog.Error("failed to read config file", "error", err)
+		os.Exit(1)
+	}
+
+	logFormat, err := setupLogging(configFile)
+	if err != nil {
+		slog.Error("failed to set up logging", "error", err)
+		os.Exit(1)
 	}
 
 	// Create the database connection
 	db, err := sql.Open("postgres", configFile.DBUrl)
 	if err != nil {
-		log.Fatalf("failed to create database connection: %v", err)
+		slog.Error("failed to create database connection", "error", err)
+		os.Exit(1)
 	}
 	dbQueries := database.New(db)
 
@@ -557,6 +676,7 @@ func main() {
 	appState := &state {
 		database: dbQueries, 
 		config: configFile,
+		db: db,
 	}
 
 	// Initialize the command handlers
@@ -567,18 +687,45 @@ func main() {
 	// Register the command handlers
 	commands.register("login", handleLogin)
 	commands.register("register", handleRegister)
-	commands.register("reset", handleReset)
-	commands.register("users", handleUsers)
+	commands.register("logout", handleLogout)
+	commands.register("passwd", middlewareLoggedIn(handlePasswd))
+	commands.register("reset", middlewareAdmin(handleReset))
+	commands.register("users", middlewareAdmin(handleUsers))
+	commands.register("user", middlewareLoggedIn(handleUser))
 	commands.register("agg", handleAggregate)
 	commands.register("addfeed", middlewareLoggedIn(handleAddFeed))
 	commands.register("feeds", handleFeeds)
+	commands.register("feed", middlewareLoggedIn(handleFeed))
 	commands.register("follow", middlewareLoggedIn(handleFollow))
 	commands.register("following", middlewareLoggedIn(handleFollowing))
 	commands.register("unfollow", middlewareLoggedIn(handleUnfollow))
 	commands.register("browse", middlewareLoggedIn(handleBrowse))
+	commands.register("read", middlewareLoggedIn(handleRead))
+	commands.register("unread", middlewareLoggedIn(handleUnread))
+	commands.register("markallread", middlewareLoggedIn(handleMarkAllRead))
+	commands.register("star", middlewareLoggedIn(handleStar))
+	commands.register("unstar", middlewareLoggedIn(handleUnstar))
+	commands.register("starred", middlewareLoggedIn(handleStarred))
+	commands.register("search", middlewareLoggedIn(handleSearch))
+	commands.register("savedsearch", middlewareLoggedIn(handleSavedSearch))
+	commands.register("filter", middlewareLoggedIn(handleFilter))
+	commands.register("rules", middlewareLoggedIn(handleRules))
+	commands.register("tags", middlewareLoggedIn(handleTags))
+	commands.register("folder", middlewareLoggedIn(handleFolder))
+	commands.register("import", middlewareLoggedIn(handleImport))
+	commands.register("export", middlewareLoggedIn(handleExport))
+	commands.register("backup", middlewareAdmin(handleBackup))
+	commands.register("restore", middlewareAdmin(handleRestore))
+	commands.register("apikey", middlewareLoggedIn(handleAPIKey))
+	commands.register("serve", handleServe)
+	commands.register("river", middlewareLoggedIn(handleRiver))
+	commands.register("webhook", middlewareLoggedIn(handleWebhook))
+	commands.register("digest", handleDigest)
+	commands.register("notify", middlewareLoggedIn(handleNotify))
 	
 	if len(os.Args) < 2 {
-		log.Fatalf("you did not provide any arguments. Usage of gator is: gator <command> <args>")
+		fmt.Fprintln(os.Stderr, "you did not provide any arguments. Usage of gator is: gator <command> <args>")
+		os.Exit(1)
 	}
 
 	newCommand := command {
@@ -587,6 +734,13 @@ func main() {
 	}
 
 	if err := commands.run(appState, newCommand); err != nil {
-		log.Fatalf("failed to run command: \"%v\"\n%v", newCommand.name, err)
+		// Errors of commands are meant for people and often include the usage,
+		// they are only logged as records when the logs are machine readable
+		if logFormat == logFormatJSON {
+			slog.Error("failed to run command", "command", newCommand.name, "error", err)
+		} else {
+			fmt.Fprintf(os.Stderr, "failed to run command: \"%v\"\n%v\n", newCommand.name, err)
+		}
+		os.Exit(1)
 	}
 }
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/database"
+	"golang.org/x/term"
+)
+
+// Notification commands which take longer are killed, they must not hold up
+// the aggregation
+const notifyCommandTimeout = 10 * time.Second
+
+const notifyUsage = `Usage:
+  gator notify <feed_url> on|off`
+
+// handleNotify marks a followed feed for notifications. While "gator agg"
+// runs, new posts of marked feeds are shown as they are aggregated.
+func handleNotify(s *state, cmd command, user database.User) error {
+	if len(cmd.args) < 2 || (cmd.args[1] != "on" && cmd.args[1] != "off") {
+		return fmt.Errorf("the notify command requires a feed URL and on|off.\n%v", notifyUsage)
+	}
+
+	notify := cmd.args[1] == "on"
+
+	feed, err := findFollowedFeed(s, user.ID, cmd.args[0])
+	if err != nil {
+		return err
+	}
+
+	err = s.database.SetFeedFollowNotify(context.Background(), database.SetFeedFollowNotifyParams{
+		Notify: notify,
+		UserID: user.ID,
+		FeedID: feed.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update feed follow: %v", err)
+	}
+
+	fmt.Printf("Notifications for %v turned %v\n", feed.Name, cmd.args[1])
+
+	return nil
+}
+
+// notifyFollowers shows the new post to every user following its feed with
+// notifications on. With a notify_command in the config the command is run
+// for every notification, otherwise the post is printed, highlighted when
+// the output is a terminal.
+func notifyFollowers(s *state, feed database.Feed, post database.Post) error {
+	userNames, err := s.database.GetNotifyFollowersForPost(context.Background(), post.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, userName := range userNames {
+		if len(s.config.NotifyCommand) > 0 {
+			if err := runNotifyCommand(s.config.NotifyCommand, userName, feed, post); err != nil {
+				slog.Warn("failed to run notify command", "user", userName, "post_id", post.ID, "error", err)
+			}
+			continue
+		}
+
+		printNotification(userName, feed, post)
+	}
+
+	return nil
+}
+
+// printNotification prints a new post to the terminal. Titles, names and URLs
+// come from the feed and are sanitized so they cannot inject escape sequences.
+func printNotification(userName string, feed database.Feed, post database.Post) {
+	title := sanitizeText(post.Title)
+	if term.IsTerminal(int(os.Stdout.Fd())) {
+		title = ansiHighlight + title + ansiReset
+	}
+
+	fmt.Printf("🔔 [%v] %v: %v\n", userName, sanitizeText(feed.Name), title)
+	if postURL := sanitizeText(post.Url); postURL != "" {
+		fmt.Printf("   %v\n", postURL)
+	}
+}
+
+// runNotifyCommand runs the configured command without a shell. The
+// placeholders {user}, {feed}, {title} and {url} in its arguments are
+// replaced, e.g. ["notify-send", "{feed}", "{title}"]. The values are also
+// passed in the GATOR_USER, GATOR_FEED, GATOR_TITLE and GATOR_URL variables.
+func runNotifyCommand(command []string, userName string, feed database.Feed, post database.Post) error {
+	replacer := strings.NewReplacer(
+		"{user}", userName,
+		"{feed}", feed.Name,
+		"{title}", post.Title,
+		"{url}", post.Url,
+	)
+
+	args := make([]string, len(command))
+	for i, arg := range command {
+		args[i] = replacer.Replace(arg)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), notifyCommandTimeout)
+	defer cancel()
+
+	notifyCmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	notifyCmd.Env = append(os.Environ(),
+		"GATOR_USER="+userName,
+		"GATOR_FEED="+feed.Name,
+		"GATOR_TITLE="+post.Title,
+		"GATOR_URL="+post.Url,
+	)
+
+	output, err := notifyCmd.CombinedOutput()
+	if err != nil {
+		if len(output) > 0 {
+			return fmt.Errorf("%v: %v", err, strings.TrimSpace(string(output)))
+		}
+
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+var ErrConfirmationRequired = errors.New("confirmation required, run the command in a terminal or pass --yes")
+var ErrPasswordMismatch = errors.New("the passwords do not match")
+
+// stdinReader is shared by all prompts so that buffered input is not lost
+// between them when answers are piped in.
+var stdinReader = bufio.NewReader(os.Stdin)
+
+func stdinIsTerminal() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+func readLine() (string, error) {
+	line, err := stdinReader.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("failed to read from stdin: %v", err)
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// confirm asks the user to type "yes". When stdin is not a terminal (e.g. in
+// scripts) it does not guess, destructive commands then need an explicit
+// --yes flag instead.
+func confirm(question string) (bool, error) {
+	if !stdinIsTerminal() {
+		return false, ErrConfirmationRequired
+	}
+
+	fmt.Printf("%v Type \"yes\" to continue: ", question)
+
+	answer, err := readLine()
+	if err != nil {
+		return false, err
+	}
+
+	return strings.EqualFold(strings.TrimSpace(answer), "yes"), nil
+}
+
+// readPassword prompts for a password without echoing it. When stdin is not a
+// terminal the password is read from the next line of stdin instead, so it
+// can be piped in by scripts.
+func readPassword(prompt string) (string, error) {
+	if !stdinIsTerminal() {
+		return readLine()
+	}
+
+	fmt.Print(prompt)
+	password, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println()
+	if err != nil {
+		return "", fmt.Errorf("failed to read password: %v", err)
+	}
+
+	return string(password), nil
+}
+
+// readNewPassword prompts for a new password, asking for it twice when typed
+// in a terminal. An empty password means no password.
+func readNewPassword(prompt string) (string, error) {
+	password, err := readPassword(prompt)
+	if err != nil {
+		return "", err
+	}
+
+	if password == "" || !stdinIsTerminal() {
+		return password, nil
+	}
+
+	confirmation, err := readPassword("Repeat the password: ")
+	if err != nil {
+		return "", err
+	}
+	if confirmation != password {
+		return "", ErrPasswordMismatch
+	}
+
+	return password, nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/database"
+	"github.com/google/uuid"
+)
+
+var ErrPostNotFound = errors.New("post not found")
+
+// findPostForUser looks up a post by its ID, making sure it belongs to one of
+// the feeds the user follows.
+func findPostForUser(s *state, postIDArg string, userID uuid.UUID) (database.FindPostForUserRow, error) {
+	postID, err := uuid.Parse(postIDArg)
+	if err != nil {
+		return database.FindPostForUserRow{}, fmt.Errorf("invalid post ID %q: %v", postIDArg, err)
+	}
+
+	post, err := s.database.FindPostForUser(context.Background(), database.FindPostForUserParams{
+		ID:     postID,
+		UserID: userID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return database.FindPostForUserRow{}, ErrPostNotFound
+		}
+
+		return database.FindPostForUserRow{}, fmt.Errorf("failed to find post: %v", err)
+	}
+
+	return post, nil
+}
+
+func handleRead(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return fmt.Errorf("the read command requires a post ID. Usage: gator read <post_id>")
+	}
+
+	post, err := findPostForUser(s, cmd.args[0], user.ID)
+	if err != nil {
+		return err
+	}
+
+	err = s.database.MarkPostRead(context.Background(), database.MarkPostReadParams{
+		UserID: user.ID,
+		PostID: post.ID,
+		ReadAt: time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark post as read: %v", err)
+	}
+
+	fmt.Printf("Marked as read: %v\n", post.Title)
+
+	return nil
+}
+
+func handleUnread(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return fmt.Errorf("the unread command requires a post ID. Usage: gator unread <post_id>")
+	}
+
+	post, err := findPostForUser(s, cmd.args[0], user.ID)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.database.MarkPostUnread(context.Background(), database.MarkPostUnreadParams{
+		UserID: user.ID,
+		PostID: post.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark post as unread: %v", err)
+	}
+
+	fmt.Printf("Marked as unread: %v\n", post.Title)
+
+	return nil
+}
+
+func handleMarkAllRead(s *state, cmd command, user database.User) error {
+	feed := ""
+	if len(cmd.args) > 0 {
+		feed = cmd.args[0]
+	}
+
+	markedCount, err := s.database.MarkAllPostsRead(context.Background(), database.MarkAllPostsReadParams{
+		ReadAt: time.Now(),
+		UserID: user.ID,
+		Feed:   nullString(feed),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark posts as read: %v", err)
+	}
+
+	postfix := "post"
+	if markedCount != 1 {
+		postfix += "s"
+	}
+	fmt.Printf("Marked %v %v as read\n", markedCount, postfix)
+
+	return nil
+}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/database"
+	"github.com/google/uuid"
+	"github.com/mattn/go-runewidth"
+	"github.com/rivo/uniseg"
+	"golang.org/x/term"
+)
+
+const (
+	defaultTerminalWidth = 80
+	minBoxWidth          = 40
+	maxBoxWidth          = 120
+	maxDescriptionLines  = 8
+	ellipsis             = "..."
+)
+
+// terminalWidth returns the width of the terminal attached to stdout. When
+// stdout is not a terminal (e.g. output is piped) it falls back to $COLUMNS
+// and then to a sensible default.
+func terminalWidth() int {
+	if width, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && width > 0 {
+		return width
+	}
+
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+
+	return defaultTerminalWidth
+}
+
+// boxWidth clamps the terminal width to something that still renders a
+// readable box.
+func boxWidth() int {
+	return min(max(terminalWidth(), minBoxWidth), maxBoxWidth)
+}
+
+// displayWidth returns the number of terminal columns the string occupies,
+// taking wide (east asian, emoji) and zero-width (combining) characters into
+// account.
+func displayWidth(str string) int {
+	return runewidth.StringWidth(str)
+}
+
+// truncateString shortens the string to fit into maxWidth terminal columns
+// without splitting multi-byte characters or grapheme clusters.
+func truncateString(str string, maxWidth int) string {
+	return runewidth.Truncate(str, maxWidth, ellipsis)
+}
+
+// padRight pads the string with spaces until it occupies width columns.
+func padRight(str string, width int) string {
+	return runewidth.FillRight(str, width)
+}
+
+// sanitizeText collapses all whitespace (including new lines and tabs) into
+// single spaces and drops control characters which would break the layout.
+func sanitizeText(str string) string {
+	cleaned := strings.Map(func(r rune) rune {
+		if r == '\t' || r == '\n' || r == '\r' {
+			return ' '
+		}
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, str)
+
+	return strings.Join(strings.Fields(cleaned), " ")
+}
+
+// splitWord breaks a word that does not fit into a single line into chunks of
+// at most width columns, splitting only at grapheme cluster boundaries.
+func splitWord(word string, width int) []string {
+	var chunks []string
+	var current strings.Builder
+	currentWidth := 0
+
+	graphemes := uniseg.NewGraphemes(word)
+	for graphemes.Next() {
+		cluster := graphemes.Str()
+		clusterWidth := displayWidth(cluster)
+
+		if currentWidth+clusterWidth > width && current.Len() > 0 {
+			chunks = append(chunks, current.String())
+			current.Reset()
+			currentWidth = 0
+		}
+
+		current.WriteString(cluster)
+		currentWidth += clusterWidth
+	}
+
+	if current.Len() > 0 {
+		chunks = append(chunks, current.String())
+	}
+
+	return chunks
+}
+
+// wrapText word-wraps the string into lines of at most width columns.
+func wrapText(str string, width int) []string {
+	if width <= 0 {
+		return nil
+	}
+
+	var lines []string
+	var current strings.Builder
+	currentWidth := 0
+
+	flush := func() {
+		lines = append(lines, current.String())
+		current.Reset()
+		currentWidth = 0
+	}
+
+	for _, word := range strings.Fields(str) {
+		wordWidth := displayWidth(word)
+
+		if wordWidth > width {
+			for _, chunk := range splitWord(word, width) {
+				chunkWidth := displayWidth(chunk)
+				if currentWidth > 0 && currentWidth+1+chunkWidth > width {
+					flush()
+				}
+				if currentWidth > 0 {
+					current.WriteString(" ")
+					currentWidth++
+				}
+				current.WriteString(chunk)
+				currentWidth += chunkWidth
+			}
+			continue
+		}
+
+		if currentWidth > 0 && currentWidth+1+wordWidth > width {
+			flush()
+		}
+		if currentWidth > 0 {
+			current.WriteString(" ")
+			currentWidth++
+		}
+		current.WriteString(word)
+		currentWidth += wordWidth
+	}
+
+	if current.Len() > 0 {
+		flush()
+	}
+
+	return lines
+}
+
+// limitLines keeps at most maxLines lines and marks the last one with an
+// ellipsis when something has been cut off.
+func limitLines(lines []string, maxLines int, width int) []string {
+	if len(lines) <= maxLines {
+		return lines
+	}
+
+	limited := lines[:maxLines]
+	last := limited[maxLines-1]
+	if displayWidth(last)+len(ellipsis) > width {
+		last = runewidth.Truncate(last, width-len(ellipsis), "")
+	}
+	limited[maxLines-1] = last + ellipsis
+
+	return limited
+}
+
+type box struct {
+	w     io.Writer
+	width int
+}
+
+func newBox(w io.Writer, width int) *box {
+	return &box{w: w, width: width}
+}
+
+// innerWidth is the usable width between the "│ " and " │" borders.
+func (b *box) innerWidth() int {
+	return b.width - 4
+}
+
+func (b *box) border(left, right string) {
+	fmt.Fprintf(b.w, "%s%s%s\n", left, strings.Repeat("─", b.width-2), right)
+}
+
+func (b *box) top() {
+	b.border("┌", "┐")
+}
+
+func (b *box) separator() {
+	b.border("├", "┤")
+}
+
+func (b *box) bottom() {
+	b.border("└", "┘")
+}
+
+func (b *box) line(content string) {
+	fmt.Fprintf(b.w, "│ %s │\n", padRight(content, b.innerWidth()))
+}
+
+// field renders "label value", wrapping the value onto continuation lines
+// indented to line up with the first one.
+func (b *box) field(label string, value string) {
+	prefix := label + " "
+	prefixWidth := displayWidth(prefix)
+
+	lines := wrapText(sanitizeText(value), b.innerWidth()-prefixWidth)
+	if len(lines) == 0 {
+		lines = []string{""}
+	}
+
+	b.line(prefix + lines[0])
+	indent := strings.Repeat(" ", prefixWidth)
+	for _, line := range lines[1:] {
+		b.line(indent + line)
+	}
+}
+
+// truncatedField renders "label value" on a single line, truncating the value
+// when it does not fit. Used for values which should not be wrapped (URLs).
+func (b *box) truncatedField(label string, value string) {
+	prefix := label + " "
+	b.line(prefix + truncateString(sanitizeText(value), b.innerWidth()-displayWidth(prefix)))
+}
+
+func (b *box) paragraph(text string, maxLines int) {
+	lines := wrapText(sanitizeText(text), b.innerWidth())
+	if len(lines) == 0 {
+		lines = []string{"N/A"}
+	}
+
+	for _, line := range limitLines(lines, maxLines, b.innerWidth()) {
+		b.line(line)
+	}
+}
+
+// postView holds everything needed to render a single post, independently of
+// which query it was loaded by.
+type postView struct {
+	ID          uuid.UUID
+	FeedName    string
+	Title       string
+	Author      string
+	Url         string
+	Description string
+	PublishedAt sql.NullTime
+	IsRead      bool
+	IsStarred   bool
+	IsFiltered  bool
+	Tags        []string
+	Note        string
+}
+
+func postViewFromUserPost(post database.GetPostsForUserRow) postView {
+	return postView{
+		ID:          post.ID,
+		FeedName:    post.FeedName,
+		Title:       post.Title,
+		Author:      post.Author,
+		Url:         post.Url,
+		Description: post.Description,
+		PublishedAt: post.PublishedAt,
+		IsRead:      post.IsRead,
+		IsStarred:   post.IsStarred,
+		IsFiltered:  post.IsFiltered,
+		Tags:        post.Tags,
+	}
+}
+
+func postViewFromBookmark(post database.GetBookmarksForUserRow) postView {
+	return postView{
+		ID:          post.ID,
+		FeedName:    post.FeedName,
+		Title:       post.Title,
+		Author:      post.Author,
+		Url:         post.Url,
+		Description: post.Description,
+		PublishedAt: post.PublishedAt,
+		IsRead:      post.IsRead,
+		IsStarred:   true,
+		Note:        post.Note,
+	}
+}
+
+func renderPost(w io.Writer, post postView, width int) {
+	publishedAtStr := "N/A"
+	if post.PublishedAt.Valid {
+		publishedAtStr = post.PublishedAt.Time.Format("02 January 2006 15:04")
+	}
+
+	status := "unread"
+	if post.IsRead {
+		status = "read"
+	}
+	if post.IsStarred {
+		status += ", starred"
+	}
+	if post.IsFiltered {
+		status += ", filtered"
+	}
+
+	b := newBox(w, width)
+	b.top()
+	b.field("ID:", fmt.Sprintf("%v (%v)", post.ID, status))
+	b.field("Title:", post.Title)
+	b.field("Feed:", post.FeedName)
+	if post.Author != "" {
+		b.field("Author:", post.Author)
+	}
+	b.field("Published At:", publishedAtStr)
+	if len(post.Tags) > 0 {
+		b.field("Tags:", strings.Join(post.Tags, ", "))
+	}
+	if post.Note != "" {
+		b.field("Note:", post.Note)
+	}
+	b.separator()
+	b.line("Description:")
+	b.paragraph(post.Description, maxDescriptionLines)
+	b.separator()
+	b.truncatedField("URL:", post.Url)
+	b.bottom()
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/database"
+)
+
+const resetUsage = "Usage: gator reset [--posts-only | --user name | --feeds-older-than 30d|2006-01-02] [--dry-run] [--yes]"
+
+type resetCount struct {
+	label string
+	count int64
+}
+
+// resetPlan describes what a reset is going to delete before anything is
+// deleted, so it can be previewed with --dry-run and confirmed.
+type resetPlan struct {
+	description string
+	counts      []resetCount
+	kept        []resetCount
+	run         func() error
+}
+
+func handleReset(s *state, cmd command) error {
+	flags := newFlagSet(cmd.name)
+	yes := flags.Bool("yes", false, "do not ask for confirmation")
+	dryRun := flags.Bool("dry-run", false, "only print what would be deleted")
+	postsOnly := flags.Bool("posts-only", false, "only delete posts, starred posts are kept")
+	userName := flags.String("user", "", "only delete this user and everything it owns")
+	feedsOlderThan := flags.String("feeds-older-than", "", "only delete feeds added before a duration (30d) or date (2006-01-02)")
+
+	args, err := parseFlags(flags, cmd.args)
+	if err != nil {
+		return fmt.Errorf("%v\n%v", err, resetUsage)
+	}
+	if len(args) > 0 {
+		return fmt.Errorf("unexpected arguments: %v\n%v", args, resetUsage)
+	}
+
+	scopes := 0
+	for _, set := range []bool{*postsOnly, *userName != "", *feedsOlderThan != ""} {
+		if set {
+			scopes++
+		}
+	}
+	if scopes > 1 {
+		return fmt.Errorf("--posts-only, --user and --feeds-older-than cannot be used together\n%v", resetUsage)
+	}
+
+	var plan resetPlan
+	switch {
+	case *postsOnly:
+		plan, err = planPostsReset(s)
+	case *userName != "":
+		plan, err = planUserReset(s, *userName)
+	case *feedsOlderThan != "":
+		plan, err = planFeedsReset(s, *feedsOlderThan)
+	default:
+		plan, err = planFullReset(s)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%v\n", plan.description)
+	for _, count := range plan.counts {
+		fmt.Printf("- %v: %v\n", count.label, count.count)
+	}
+	for _, count := range plan.kept {
+		fmt.Printf("- %v (kept): %v\n", count.label, count.count)
+	}
+
+	if *dryRun {
+		fmt.Println("Dry run, nothing has been deleted")
+		return nil
+	}
+
+	if !*yes {
+		confirmed, err := confirm("This cannot be undone.")
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			fmt.Println("Reset cancelled")
+			return nil
+		}
+	}
+
+	if err := plan.run(); err != nil {
+		return err
+	}
+
+	fmt.Println("Database has been reset")
+
+	return nil
+}
+
+func planFullReset(s *state) (resetPlan, error) {
+	counts, err := s.database.CountResetAll(context.Background())
+	if err != nil {
+		return resetPlan{}, fmt.Errorf("failed to count rows: %v", err)
+	}
+
+	return resetPlan{
+		description: "The reset will delete every user together with all feeds, follows and posts:",
+		counts: []resetCount{
+			{"Users", counts.Users},
+			{"Feeds", counts.Feeds},
+			{"Feed follows", counts.FeedFollows},
+			{"Posts", counts.Posts},
+		},
+		run: func() error {
+			if err := s.database.DeleteAllUsers(context.Background()); err != nil {
+				return fmt.Errorf("failed to reset database: %v", err)
+			}
+			return nil
+		},
+	}, nil
+}
+
+func planPostsReset(s *state) (resetPlan, error) {
+	counts, err := s.database.CountResetPosts(context.Background())
+	if err != nil {
+		return resetPlan{}, fmt.Errorf("failed to count rows: %v", err)
+	}
+
+	return resetPlan{
+		description: "The reset will delete every post which has not been starred:",
+		counts: []resetCount{
+			{"Posts", counts.Posts},
+		},
+		kept: []resetCount{
+			{"Starred posts", counts.StarredPosts},
+		},
+		run: func() error {
+			if _, err := s.database.DeleteUnstarredPosts(context.Background()); err != nil {
+				return fmt.Errorf("failed to delete posts: %v", err)
+			}
+			return nil
+		},
+	}, nil
+}
+
+func planUserReset(s *state, userName string) (resetPlan, error) {
+	user, err := s.database.FindUserByeName(context.Background(), userName)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return resetPlan{}, fmt.Errorf("%w: %v", ErrUserNotFound, userName)
+		}
+		return resetPlan{}, fmt.Errorf("failed to find user: %v", err)
+	}
+
+	if err := checkLastAdmin(s, user); err != nil {
+		return resetPlan{}, err
+	}
+
+	counts, err := s.database.CountResetUser(context.Background(), user.ID)
+	if err != nil {
+		return resetPlan{}, fmt.Errorf("failed to count rows: %v", err)
+	}
+
+	return resetPlan{
+		description: fmt.Sprintf("The reset will delete the user %v together with the feeds it added (also for their other followers):", user.Name),
+		counts: []resetCount{
+			{"Users", 1},
+			{"Feeds", counts.Feeds},
+			{"Feed follows", counts.FeedFollows},
+			{"Posts", counts.Posts},
+		},
+		run: func() error {
+			if err := deleteUser(s, user); err != nil {
+				return err
+			}
+			return nil
+		},
+	}, nil
+}
+
+func planFeedsReset(s *state, olderThan string) (resetPlan, error) {
+	cutoff, err := parseTimeFilter(olderThan, time.Now())
+	if err != nil {
+		return resetPlan{}, fmt.Errorf("invalid --feeds-older-than value: %v", err)
+	}
+
+	counts, err := s.database.CountResetFeedsCreatedBefore(context.Background(), cutoff)
+	if err != nil {
+		return resetPlan{}, fmt.Errorf("failed to count rows: %v", err)
+	}
+
+	return resetPlan{
+		description: fmt.Sprintf("The reset will delete the feeds added before %v together with their posts:", cutoff.Format("02 January 2006 15:04")),
+		counts: []resetCount{
+			{"Feeds", counts.Feeds},
+			{"Feed follows", counts.FeedFollows},
+			{"Posts", counts.Posts},
+		},
+		run: func() error {
+			if _, err := s.database.DeleteFeedsCreatedBefore(context.Background(), cutoff); err != nil {
+				return fmt.Errorf("failed to delete feeds: %v", err)
+			}
+			return nil
+		},
+	}, nil
+}
+
+// deleteUser deletes the user with everything it owns and logs out when it
+// was the current user.
+func deleteUser(s *state, user database.User) error {
+	if _, err := s.database.DeleteUser(context.Background(), user.ID); err != nil {
+		return fmt.Errorf("failed to delete user: %v", err)
+	}
+
+	if s.config.CurrentUserName != nil && *s.config.CurrentUserName == user.Name {
+		if err := s.config.ClearSession(); err != nil {
+			return fmt.Errorf("failed to log out the deleted user: %v", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,647 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/database"
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/rules"
+	"github.com/google/uuid"
+)
+
+const restoreUsage = "Usage: gator restore <file>"
+
+// maxBackupLineSize bounds a single archive line, posts with their full
+// content can be large.
+const maxBackupLineSize = 64 * 1024 * 1024
+
+type restoreCount struct {
+	restored int
+	skipped  int
+}
+
+// restorer imports the rows of an archive. Rows which already exist (matched
+// by their natural key, e.g. the user name or the feed URL) are skipped and
+// the IDs of the archive are mapped to the IDs of the existing rows, so
+// restoring the same archive twice is a no-op.
+type restorer struct {
+	queries *database.Queries
+	counts  map[string]*restoreCount
+
+	users   map[uuid.UUID]uuid.UUID
+	feeds   map[uuid.UUID]uuid.UUID
+	posts   map[uuid.UUID]uuid.UUID
+	folders map[uuid.UUID]uuid.UUID
+	digests map[uuid.UUID]uuid.UUID
+}
+
+func newRestorer(queries *database.Queries) *restorer {
+	return &restorer{
+		queries: queries,
+		counts:  map[string]*restoreCount{},
+		users:   map[uuid.UUID]uuid.UUID{},
+		feeds:   map[uuid.UUID]uuid.UUID{},
+		posts:   map[uuid.UUID]uuid.UUID{},
+		folders: map[uuid.UUID]uuid.UUID{},
+		digests: map[uuid.UUID]uuid.UUID{},
+	}
+}
+
+func (r *restorer) count(recordType string, restored bool) {
+	count, ok := r.counts[recordType]
+	if !ok {
+		count = &restoreCount{}
+		r.counts[recordType] = count
+	}
+
+	if restored {
+		count.restored++
+	} else {
+		count.skipped++
+	}
+}
+
+func mapID(ids map[uuid.UUID]uuid.UUID, recordType string, id uuid.UUID) (uuid.UUID, error) {
+	mappedID, ok := ids[id]
+	if !ok {
+		return uuid.Nil, fmt.Errorf("%w: unknown %v %v", ErrInvalidBackup, recordType, id)
+	}
+	return mappedID, nil
+}
+
+// restoredRole defaults the role of users from archives created before roles
+// existed.
+func restoredRole(role string) string {
+	if role == "" {
+		return roleUser
+	}
+	return role
+}
+
+func handleRestore(s *state, cmd command) error {
+	if len(cmd.args) == 0 {
+		return fmt.Errorf("the restore command requires a backup file.\n%v", restoreUsage)
+	}
+
+	file, err := os.Open(cmd.args[0])
+	if err != nil {
+		return fmt.Errorf("failed to open backup file: %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxBackupLineSize)
+
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return fmt.Errorf("failed to read backup file: %v", err)
+		}
+		return fmt.Errorf("%w: the file is empty", ErrInvalidBackup)
+	}
+
+	header, err := readBackupHeader(scanner.Bytes())
+	if err != nil {
+		return err
+	}
+
+	version, err := schemaVersion(s)
+	if err != nil {
+		return err
+	}
+	if header.SchemaVersion > version {
+		return fmt.Errorf("the backup was created with schema version %v but the database is at version %v, run the migrations first", header.SchemaVersion, version)
+	}
+
+	tx, err := s.db.BeginTx(context.Background(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	r := newRestorer(s.database.WithTx(tx))
+
+	line := 1
+	for scanner.Scan() {
+		line++
+
+		var record backupRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return fmt.Errorf("%w: line %v: %v", ErrInvalidBackup, line, err)
+		}
+
+		if err := r.restore(record); err != nil {
+			return fmt.Errorf("failed to restore line %v: %v", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read backup file: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit the restore: %v", err)
+	}
+
+	fmt.Printf("Backup restored from %v\n", cmd.args[0])
+	fmt.Printf("- Created at: %v\n", header.CreatedAt.Format("02 January 2006 15:04"))
+	fmt.Printf("- Schema version: %v\n", header.SchemaVersion)
+	for _, recordType := range backupTypes {
+		count := r.counts[recordType]
+		if count == nil {
+			count = &restoreCount{}
+		}
+		fmt.Printf("- %v: %v restored, %v already existed\n", recordType, count.restored, count.skipped)
+	}
+	printBackupExcluded(os.Stdout)
+
+	return nil
+}
+
+func readBackupHeader(line []byte) (backupHeader, error) {
+	var record backupRecord
+	if err := json.Unmarshal(line, &record); err != nil || record.Type != backupTypeHeader {
+		return backupHeader{}, fmt.Errorf("%w: missing header", ErrInvalidBackup)
+	}
+
+	var header backupHeader
+	if err := json.Unmarshal(record.Data, &header); err != nil {
+		return backupHeader{}, fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+	}
+
+	if header.Format != backupFormat {
+		return backupHeader{}, fmt.Errorf("%w: unknown format %q", ErrInvalidBackup, header.Format)
+	}
+	if header.Version > backupVersion {
+		return backupHeader{}, fmt.Errorf("%w: version %v is newer than the supported version %v", ErrInvalidBackup, header.Version, backupVersion)
+	}
+
+	return header, nil
+}
+
+func (r *restorer) restore(record backupRecord) error {
+	ctx := context.Background()
+
+	switch record.Type {
+	case backupTypeUser:
+		var user backupUser
+		if err := json.Unmarshal(record.Data, &user); err != nil {
+			return err
+		}
+
+		restored, err := r.queries.RestoreUser(ctx, database.RestoreUserParams{
+			ID:           user.ID,
+			Name:         user.Name,
+			CreatedAt:    user.CreatedAt,
+			UpdatedAt:    user.UpdatedAt,
+			PasswordHash: user.PasswordHash,
+			Role:         restoredRole(user.Role),
+		})
+		if err != nil {
+			return err
+		}
+
+		r.users[user.ID] = restored.ID
+		r.count(record.Type, restored.Inserted)
+
+	case backupTypeFeed:
+		var feed backupFeed
+		if err := json.Unmarshal(record.Data, &feed); err != nil {
+			return err
+		}
+
+		userID, err := mapID(r.users, backupTypeUser, feed.UserID)
+		if err != nil {
+			return err
+		}
+
+		restored, err := r.queries.RestoreFeed(ctx, database.RestoreFeedParams{
+			ID:            feed.ID,
+			UserID:        userID,
+			Url:           feed.Url,
+			Name:          feed.Name,
+			CreatedAt:     feed.CreatedAt,
+			LastFetchedAt: ptrToNullTime(feed.LastFetchedAt),
+			SiteUrl:       feed.SiteUrl,
+		})
+		if err != nil {
+			return err
+		}
+
+		r.feeds[feed.ID] = restored.ID
+		r.count(record.Type, restored.Inserted)
+
+	case backupTypeFeedFollow:
+		var feedFollow backupFeedFollow
+		if err := json.Unmarshal(record.Data, &feedFollow); err != nil {
+			return err
+		}
+
+		userID, err := mapID(r.users, backupTypeUser, feedFollow.UserID)
+		if err != nil {
+			return err
+		}
+		feedID, err := mapID(r.feeds, backupTypeFeed, feedFollow.FeedID)
+		if err != nil {
+			return err
+		}
+
+		restoredCount, err := r.queries.RestoreFeedFollow(ctx, database.RestoreFeedFollowParams{
+			ID:        feedFollow.ID,
+			UserID:    userID,
+			FeedID:    feedID,
+			CreatedAt: feedFollow.CreatedAt,
+			Notify:    feedFollow.Notify,
+		})
+		if err != nil {
+			return err
+		}
+
+		r.count(record.Type, restoredCount > 0)
+
+	case backupTypePost:
+		var post backupPost
+		if err := json.Unmarshal(record.Data, &post); err != nil {
+			return err
+		}
+
+		feedID, err := mapID(r.feeds, backupTypeFeed, post.FeedID)
+		if err != nil {
+			return err
+		}
+
+		categories := post.Categories
+		if categories == nil {
+			categories = []string{}
+		}
+
+		restored, err := r.queries.RestorePost(ctx, database.RestorePostParams{
+			ID:          post.ID,
+			FeedID:      feedID,
+			Title:       post.Title,
+			Url:         post.Url,
+			Description: post.Description,
+			PublishedAt: ptrToNullTime(post.PublishedAt),
+			CreatedAt:   post.CreatedAt,
+			UpdatedAt:   post.UpdatedAt,
+			Author:      post.Author,
+			Content:     post.Content,
+			Categories:  categories,
+		})
+		if err != nil {
+			return err
+		}
+
+		r.posts[post.ID] = restored.ID
+		r.count(record.Type, restored.Inserted)
+
+	case backupTypePostRead:
+		var postRead backupPostRead
+		if err := json.Unmarshal(record.Data, &postRead); err != nil {
+			return err
+		}
+
+		userID, err := mapID(r.users, backupTypeUser, postRead.UserID)
+		if err != nil {
+			return err
+		}
+		postID, err := mapID(r.posts, backupTypePost, postRead.PostID)
+		if err != nil {
+			return err
+		}
+
+		restoredCount, err := r.queries.RestorePostRead(ctx, database.RestorePostReadParams{
+			UserID: userID,
+			PostID: postID,
+			ReadAt: postRead.ReadAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		r.count(record.Type, restoredCount > 0)
+
+	case backupTypeBookmark:
+		var bookmark backupBookmark
+		if err := json.Unmarshal(record.Data, &bookmark); err != nil {
+			return err
+		}
+
+		userID, err := mapID(r.users, backupTypeUser, bookmark.UserID)
+		if err != nil {
+			return err
+		}
+		// Bookmarks outlive their posts, those of deleted posts keep their ID
+		postID, ok := r.posts[bookmark.PostID]
+		if !ok {
+			postID = bookmark.PostID
+		}
+
+		restoredCount, err := r.queries.RestoreBookmark(ctx, database.RestoreBookmarkParams{
+			UserID:          userID,
+			PostID:          postID,
+			Note:            bookmark.Note,
+			CreatedAt:       bookmark.CreatedAt,
+			UpdatedAt:       bookmark.UpdatedAt,
+			PostTitle:       bookmark.PostTitle,
+			PostUrl:         bookmark.PostUrl,
+			PostDescription: bookmark.PostDescription,
+			PostAuthor:      bookmark.PostAuthor,
+			PostPublishedAt: ptrToNullTime(bookmark.PostPublishedAt),
+			FeedName:        bookmark.FeedName,
+		})
+		if err != nil {
+			return err
+		}
+
+		r.count(record.Type, restoredCount > 0)
+
+	case backupTypeSavedSearch:
+		var savedSearch backupSavedSearch
+		if err := json.Unmarshal(record.Data, &savedSearch); err != nil {
+			return err
+		}
+
+		userID, err := mapID(r.users, backupTypeUser, savedSearch.UserID)
+		if err != nil {
+			return err
+		}
+
+		restoredCount, err := r.queries.RestoreSavedSearch(ctx, database.RestoreSavedSearchParams{
+			ID:        savedSearch.ID,
+			UserID:    userID,
+			Name:      savedSearch.Name,
+			Query:     savedSearch.Query,
+			Notify:    savedSearch.Notify,
+			CreatedAt: savedSearch.CreatedAt,
+			UpdatedAt: savedSearch.UpdatedAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		r.count(record.Type, restoredCount > 0)
+
+	case backupTypePostFilter:
+		var postFilter backupPostFilter
+		if err := json.Unmarshal(record.Data, &postFilter); err != nil {
+			return err
+		}
+
+		userID, err := mapID(r.users, backupTypeUser, postFilter.UserID)
+		if err != nil {
+			return err
+		}
+
+		feedID := uuid.NullUUID{}
+		if postFilter.FeedID != nil {
+			mappedID, err := mapID(r.feeds, backupTypeFeed, *postFilter.FeedID)
+			if err != nil {
+				return err
+			}
+			feedID = uuid.NullUUID{UUID: mappedID, Valid: true}
+		}
+
+		restoredCount, err := r.queries.RestorePostFilter(ctx, database.RestorePostFilterParams{
+			ID:        postFilter.ID,
+			UserID:    userID,
+			FeedID:    feedID,
+			Kind:      postFilter.Kind,
+			Pattern:   postFilter.Pattern,
+			CreatedAt: postFilter.CreatedAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		r.count(record.Type, restoredCount > 0)
+
+	case backupTypeRule:
+		var rule backupRule
+		if err := json.Unmarshal(record.Data, &rule); err != nil {
+			return err
+		}
+
+		userID, err := mapID(r.users, backupTypeUser, rule.UserID)
+		if err != nil {
+			return err
+		}
+
+		restoredCount, err := r.queries.RestoreRule(ctx, database.RestoreRuleParams{
+			ID:         rule.ID,
+			UserID:     userID,
+			Name:       rule.Name,
+			Expression: rule.Expression,
+			CreatedAt:  rule.CreatedAt,
+			UpdatedAt:  rule.UpdatedAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		r.count(record.Type, restoredCount > 0)
+
+	case backupTypePostTag:
+		var postTag backupPostTag
+		if err := json.Unmarshal(record.Data, &postTag); err != nil {
+			return err
+		}
+
+		userID, err := mapID(r.users, backupTypeUser, postTag.UserID)
+		if err != nil {
+			return err
+		}
+		postID, err := mapID(r.posts, backupTypePost, postTag.PostID)
+		if err != nil {
+			return err
+		}
+
+		restoredCount, err := r.queries.RestorePostTag(ctx, database.RestorePostTagParams{
+			UserID:    userID,
+			PostID:    postID,
+			Tag:       postTag.Tag,
+			CreatedAt: postTag.CreatedAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		r.count(record.Type, restoredCount > 0)
+
+	case backupTypeFolder:
+		var folder backupFolder
+		if err := json.Unmarshal(record.Data, &folder); err != nil {
+			return err
+		}
+
+		userID, err := mapID(r.users, backupTypeUser, folder.UserID)
+		if err != nil {
+			return err
+		}
+
+		restored, err := r.queries.RestoreFolder(ctx, database.RestoreFolderParams{
+			ID:        folder.ID,
+			UserID:    userID,
+			Name:      folder.Name,
+			CreatedAt: folder.CreatedAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		r.folders[folder.ID] = restored.ID
+		r.count(record.Type, restored.Inserted)
+
+	case backupTypeFolderFeed:
+		var folderFeed backupFolderFeed
+		if err := json.Unmarshal(record.Data, &folderFeed); err != nil {
+			return err
+		}
+
+		folderID, err := mapID(r.folders, backupTypeFolder, folderFeed.FolderID)
+		if err != nil {
+			return err
+		}
+		feedID, err := mapID(r.feeds, backupTypeFeed, folderFeed.FeedID)
+		if err != nil {
+			return err
+		}
+
+		restoredCount, err := r.queries.RestoreFolderFeed(ctx, database.RestoreFolderFeedParams{
+			FolderID:  folderID,
+			FeedID:    feedID,
+			CreatedAt: folderFeed.CreatedAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		r.count(record.Type, restoredCount > 0)
+
+	case backupTypeWebhook:
+		var hook backupWebhook
+		if err := json.Unmarshal(record.Data, &hook); err != nil {
+			return err
+		}
+
+		userID, err := mapID(r.users, backupTypeUser, hook.UserID)
+		if err != nil {
+			return err
+		}
+
+		feedID := uuid.NullUUID{}
+		if hook.FeedID != nil {
+			mappedID, err := mapID(r.feeds, backupTypeFeed, *hook.FeedID)
+			if err != nil {
+				return err
+			}
+			feedID = uuid.NullUUID{UUID: mappedID, Valid: true}
+		}
+
+		// Archives from before tags were normalized may hold any spelling
+		tag := sql.NullString{}
+		if hook.Tag != nil {
+			if normalized := rules.NormalizeTag(*hook.Tag); normalized != "" {
+				tag = sql.NullString{String: normalized, Valid: true}
+			}
+		}
+
+		restoredCount, err := r.queries.RestoreWebhook(ctx, database.RestoreWebhookParams{
+			ID:        hook.ID,
+			UserID:    userID,
+			Name:      hook.Name,
+			Url:       hook.Url,
+			Format:    hook.Format,
+			Secret:    hook.Secret,
+			FeedID:    feedID,
+			Tag:       tag,
+			CreatedAt: hook.CreatedAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		r.count(record.Type, restoredCount > 0)
+
+	case backupTypeDigestSubscription:
+		var subscription backupDigestSubscription
+		if err := json.Unmarshal(record.Data, &subscription); err != nil {
+			return err
+		}
+
+		userID, err := mapID(r.users, backupTypeUser, subscription.UserID)
+		if err != nil {
+			return err
+		}
+
+		restoredCount, err := r.queries.RestoreDigestSubscription(ctx, database.RestoreDigestSubscriptionParams{
+			UserID:    userID,
+			Email:     subscription.Email,
+			Frequency: subscription.Frequency,
+			CreatedAt: subscription.CreatedAt,
+			UpdatedAt: subscription.UpdatedAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		r.count(record.Type, restoredCount > 0)
+
+	case backupTypeDigest:
+		var digest backupDigest
+		if err := json.Unmarshal(record.Data, &digest); err != nil {
+			return err
+		}
+
+		userID, err := mapID(r.users, backupTypeUser, digest.UserID)
+		if err != nil {
+			return err
+		}
+
+		restored, err := r.queries.RestoreDigest(ctx, database.RestoreDigestParams{
+			ID:        digest.ID,
+			UserID:    userID,
+			Email:     digest.Email,
+			PostCount: digest.PostCount,
+			SentAt:    digest.SentAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		r.digests[digest.ID] = restored.ID
+		r.count(record.Type, restored.Inserted)
+
+	case backupTypeDigestPost:
+		var digestPost backupDigestPost
+		if err := json.Unmarshal(record.Data, &digestPost); err != nil {
+			return err
+		}
+
+		digestID, err := mapID(r.digests, backupTypeDigest, digestPost.DigestID)
+		if err != nil {
+			return err
+		}
+		postID, err := mapID(r.posts, backupTypePost, digestPost.PostID)
+		if err != nil {
+			return err
+		}
+
+		restoredCount, err := r.queries.RestoreDigestPost(ctx, database.RestoreDigestPostParams{
+			DigestID: digestID,
+			PostID:   postID,
+		})
+		if err != nil {
+			return err
+		}
+
+		r.count(record.Type, restoredCount > 0)
+
+	default:
+		return fmt.Errorf("%w: unknown record type %q", ErrInvalidBackup, record.Type)
+	}
+
+	return nil
+}
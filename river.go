@@ -0,0 +1,270 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/auth"
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/database"
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/syndication"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultRiverLimit = 50
+	maxRiverLimit     = 200
+
+	riverFormatRSS  = "rss"
+	riverFormatAtom = "atom"
+)
+
+const riverUsage = `Usage:
+  gator river token [--base-url http://localhost:8080]
+  gator river revoke`
+
+// The river is the aggregated timeline of a user, published as RSS and Atom
+// by "gator serve" so it can be read from other feed readers. Readers cannot
+// send headers, so the river is authenticated by a secret token in its URL.
+func handleRiver(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return fmt.Errorf("the river command requires a sub command.\n%v", riverUsage)
+	}
+
+	subCmd := command{
+		name: cmd.name + " " + cmd.args[0],
+		args: cmd.args[1:],
+	}
+
+	switch cmd.args[0] {
+	case "token":
+		return handleRiverToken(s, subCmd, user)
+	case "revoke":
+		return handleRiverRevoke(s, subCmd, user)
+	}
+
+	return fmt.Errorf("unknown river sub command: %v\n%v", cmd.args[0], riverUsage)
+}
+
+// handleRiverToken creates the river token of the user, replacing the
+// previous one. Only its hash is stored, so the URLs are printed once.
+func handleRiverToken(s *state, cmd command, user database.User) error {
+	flags := newFlagSet(cmd.name)
+	baseURL := flags.String("base-url", "http://"+defaultServeAddr, "public address of \"gator serve\"")
+
+	if _, err := parseFlags(flags, cmd.args); err != nil {
+		return fmt.Errorf("%v\n%v", err, riverUsage)
+	}
+
+	// River tokens are random like session tokens
+	token, err := auth.NewSessionToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate river token: %v", err)
+	}
+
+	_, err = s.database.SetRiverToken(context.Background(), database.SetRiverTokenParams{
+		UserID:    user.ID,
+		TokenHash: auth.HashSessionToken(token),
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save river token: %v", err)
+	}
+
+	riverURL := strings.TrimSuffix(*baseURL, "/") + "/river/" + token
+
+	fmt.Printf("River feeds of %v (previous river URLs no longer work)\n", user.Name)
+	fmt.Printf("- RSS: %v/rss.xml\n", riverURL)
+	fmt.Printf("- Atom: %v/atom.xml\n", riverURL)
+	fmt.Println("Narrow the river with ?folder=<name>, ?tag=<tag>, ?feed=<name|url> or ?unread=true")
+	fmt.Println("Keep the URLs secret, anyone with them can read your river")
+
+	return nil
+}
+
+func handleRiverRevoke(s *state, cmd command, user database.User) error {
+	removedCount, err := s.database.DeleteRiverToken(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke river token: %v", err)
+	}
+
+	if removedCount == 0 {
+		fmt.Println("No river token to revoke")
+		return nil
+	}
+
+	fmt.Println("River token revoked, the river URLs no longer work")
+
+	return nil
+}
+
+// parseRiverQuery maps the query parameters of a river URL to the options of
+// the browse command, the river is built from the same query.
+func parseRiverQuery(values url.Values) (browseOptions, error) {
+	opts := browseOptions{
+		limit:  defaultRiverLimit,
+		sortBy: sortByPublished,
+		feed:   values.Get("feed"),
+		folder: values.Get("folder"),
+		tag:    values.Get("tag"),
+	}
+
+	if value := values.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > maxRiverLimit {
+			return opts, fmt.Errorf("the limit must be between 1 and %v", maxRiverLimit)
+		}
+		opts.limit = limit
+	}
+
+	if value := values.Get("unread"); value != "" {
+		unread, err := strconv.ParseBool(value)
+		if err != nil {
+			return opts, fmt.Errorf("invalid unread %q", value)
+		}
+		opts.unread = unread
+	}
+
+	return opts, nil
+}
+
+// riverTitle names the river after the user and the narrowing options.
+func riverTitle(user database.User, opts browseOptions) string {
+	var scopes []string
+	if opts.folder != "" {
+		scopes = append(scopes, "folder "+opts.folder)
+	}
+	if opts.tag != "" {
+		scopes = append(scopes, "tag "+opts.tag)
+	}
+	if opts.feed != "" {
+		scopes = append(scopes, "feed "+opts.feed)
+	}
+	if opts.unread {
+		scopes = append(scopes, "unread")
+	}
+
+	title := fmt.Sprintf("gator river of %v", user.Name)
+	if len(scopes) > 0 {
+		title += " (" + strings.Join(scopes, ", ") + ")"
+	}
+
+	return title
+}
+
+func postItemID(postID uuid.UUID) string {
+	return "urn:uuid:" + postID.String()
+}
+
+func riverFeed(user database.User, opts browseOptions, selfURL string, posts []database.GetPostsForUserRow, now time.Time) *syndication.Feed {
+	title := riverTitle(user, opts)
+
+	feed := &syndication.Feed{
+		ID:          selfURL,
+		Title:       title,
+		Description: "Posts of the feeds followed by " + user.Name + " in gator",
+		SelfURL:     selfURL,
+		Updated:     now,
+	}
+
+	for i, post := range posts {
+		published := post.CreatedAt
+		if post.PublishedAt.Valid {
+			published = post.PublishedAt.Time
+		}
+
+		// The newest post tells readers when the river last changed
+		if i == 0 {
+			feed.Updated = post.SortKey
+		}
+
+		feed.Items = append(feed.Items, syndication.Item{
+			ID:          postItemID(post.ID),
+			Title:       post.Title,
+			Link:        post.Url,
+			Description: post.Description,
+			Content:     post.Content,
+			Author:      post.Author,
+			Categories:  append([]string{post.FeedName}, post.Tags...),
+			Published:   published,
+			Updated:     post.UpdatedAt,
+		})
+	}
+
+	return feed
+}
+
+// handleRiverFeed serves the river of the user owning the token of the URL
+// in the given format. Unknown tokens get a plain 404 to not tell valid and
+// revoked tokens apart.
+func (api *apiServer) handleRiverFeed(format string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := api.state.database.FindUserByRiverToken(r.Context(), auth.HashSessionToken(r.PathValue("token")))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				http.NotFound(w, r)
+				return
+			}
+
+			slog.Error("failed to check river token", "error", err)
+			http.Error(w, "failed to check river token", http.StatusInternalServerError)
+			return
+		}
+
+		opts, err := parseRiverQuery(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		now := time.Now()
+		params, err := buildPostsQuery(user.ID, opts, now)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		posts, err := getPostsForUser(r.Context(), api.state.database, opts.sortBy, params)
+		if err != nil {
+			slog.Error("failed to get posts for the river", "error", err)
+			http.Error(w, "failed to get posts", http.StatusInternalServerError)
+			return
+		}
+
+		selfURL := requestURL(r)
+		feed := riverFeed(user, opts, selfURL, posts, now)
+
+		var encode func(io.Writer) error
+		switch format {
+		case riverFormatAtom:
+			w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+			encode = feed.EncodeAtom
+		default:
+			w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+			encode = feed.EncodeRSS
+		}
+
+		if err := encode(w); err != nil {
+			slog.Error("failed to write the river", "error", err)
+		}
+	}
+}
+
+// requestURL reconstructs the absolute URL of the request, honouring the
+// headers of a TLS terminating reverse proxy.
+func requestURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+
+	return scheme + "://" + r.Host + r.URL.RequestURI()
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/database"
+)
+
+const (
+	roleUser  = "user"
+	roleAdmin = "admin"
+)
+
+var ErrAdminRequired = errors.New("this command requires an administrator")
+var ErrAdminPasswordRequired = errors.New("administrators must set a password to use this command. Use: gator passwd")
+var ErrNoAdmin = errors.New("the database has no administrator, promote a user in the database")
+var ErrLastAdmin = errors.New("the last administrator cannot be demoted")
+var ErrLastAdminDelete = errors.New("the last administrator cannot be deleted, promote another user first")
+
+func isAdmin(user database.User) bool {
+	return user.Role == roleAdmin
+}
+
+// requireAdmin checks that the user is an administrator with a password.
+// Anyone can log in as a user without a password, so their role does not
+// count. Users with a password can only be logged in with a session token.
+func requireAdmin(user database.User) error {
+	if !isAdmin(user) {
+		return ErrAdminRequired
+	}
+	if user.PasswordHash == "" {
+		return ErrAdminPasswordRequired
+	}
+
+	return nil
+}
+
+// middlewareAdmin only runs the handler for administrators. An empty database
+// (a fresh or a wiped one) has nothing to protect yet, so the handler runs
+// for anyone; the first user to register then becomes the administrator.
+func middlewareAdmin(handler func(s *state, cmd command) error) func(s *state, cmd command) error {
+	return func(s *state, cmd command) error {
+		userCount, err := s.database.CountUsers(context.Background())
+		if err != nil {
+			return fmt.Errorf("failed to count users: %v", err)
+		}
+		if userCount == 0 {
+			return handler(s, cmd)
+		}
+
+		adminCount, err := s.database.CountAdmins(context.Background())
+		if err != nil {
+			return fmt.Errorf("failed to count administrators: %v", err)
+		}
+		if adminCount == 0 {
+			return ErrNoAdmin
+		}
+
+		user, err := currentUser(s)
+		if err != nil {
+			return err
+		}
+
+		if err := requireAdmin(user); err != nil {
+			return err
+		}
+
+		return handler(s, cmd)
+	}
+}
+
+// checkLastAdmin refuses to delete the user when it is the last
+// administrator, the admin commands would be locked for everybody else.
+func checkLastAdmin(s *state, user database.User) error {
+	if !isAdmin(user) {
+		return nil
+	}
+
+	adminCount, err := s.database.CountAdmins(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to count administrators: %v", err)
+	}
+	if adminCount <= 1 {
+		return ErrLastAdminDelete
+	}
+
+	return nil
+}
+
+func findUser(s *state, name string) (database.User, error) {
+	user, err := s.database.FindUserByeName(context.Background(), name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return database.User{}, fmt.Errorf("%w: %v", ErrUserNotFound, name)
+		}
+
+		return database.User{}, fmt.Errorf("failed to find user: %v", err)
+	}
+
+	return user, nil
+}
+
+func handleUserPromote(s *state, cmd command, user database.User) error {
+	if err := requireAdmin(user); err != nil {
+		return err
+	}
+	if len(cmd.args) == 0 {
+		return fmt.Errorf("the user promote command requires a user name.\n%v", userUsage)
+	}
+
+	target, err := findUser(s, cmd.args[0])
+	if err != nil {
+		return err
+	}
+
+	if isAdmin(target) {
+		fmt.Printf("%v is already an administrator\n", target.Name)
+		return nil
+	}
+
+	err = s.database.SetUserRole(context.Background(), database.SetUserRoleParams{
+		Role:      roleAdmin,
+		UpdatedAt: time.Now(),
+		ID:        target.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to promote user: %v", err)
+	}
+
+	fmt.Printf("%v is now an administrator\n", target.Name)
+
+	return nil
+}
+
+func handleUserDemote(s *state, cmd command, user database.User) error {
+	if err := requireAdmin(user); err != nil {
+		return err
+	}
+	if len(cmd.args) == 0 {
+		return fmt.Errorf("the user demote command requires a user name.\n%v", userUsage)
+	}
+
+	target, err := findUser(s, cmd.args[0])
+	if err != nil {
+		return err
+	}
+
+	if !isAdmin(target) {
+		fmt.Printf("%v is not an administrator\n", target.Name)
+		return nil
+	}
+
+	adminCount, err := s.database.CountAdmins(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to count administrators: %v", err)
+	}
+	if adminCount <= 1 {
+		return ErrLastAdmin
+	}
+
+	err = s.database.SetUserRole(context.Background(), database.SetUserRoleParams{
+		Role:      roleUser,
+		UpdatedAt: time.Now(),
+		ID:        target.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to demote user: %v", err)
+	}
+
+	fmt.Printf("%v is no longer an administrator\n", target.Name)
+
+	return nil
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/database"
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/rules"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var ErrRuleNotFound = errors.New("rule not found")
+var ErrRuleExists = errors.New("rule already exists")
+
+const rulesUsage = `Usage:
+  gator rules add <name> <rule>
+  gator rules list
+  gator rules remove <name>
+  gator rules test <name|rule> <post_id>
+
+Rules look like: if feed = "Go Blog" and title ~ /security/i then tag sec, star`
+
+func handleRules(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return fmt.Errorf("the rules command requires a sub command.\n%v", rulesUsage)
+	}
+
+	subCmd := command{
+		name: cmd.name + " " + cmd.args[0],
+		args: cmd.args[1:],
+	}
+
+	switch cmd.args[0] {
+	case "add":
+		return handleRulesAdd(s, subCmd, user)
+	case "list":
+		return handleRulesList(s, subCmd, user)
+	case "remove":
+		return handleRulesRemove(s, subCmd, user)
+	case "test":
+		return handleRulesTest(s, subCmd, user)
+	}
+
+	return fmt.Errorf("unknown rules sub command: %v\n%v", cmd.args[0], rulesUsage)
+}
+
+func handleRulesAdd(s *state, cmd command, user database.User) error {
+	if len(cmd.args) < 2 {
+		return fmt.Errorf("the rules add command requires a name and a rule.\n%v", rulesUsage)
+	}
+
+	name := cmd.args[0]
+	expression := strings.Join(cmd.args[1:], " ")
+
+	if _, err := rules.Parse(expression); err != nil {
+		return err
+	}
+
+	rule, err := s.database.CreateRule(context.Background(), database.CreateRuleParams{
+		ID:         uuid.New(),
+		UserID:     user.ID,
+		Name:       name,
+		Expression: expression,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	})
+	if err != nil {
+		var pqErr *pq.Error
+
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return fmt.Errorf("%w: %v", ErrRuleExists, name)
+		}
+
+		return fmt.Errorf("failed to create rule: %v", err)
+	}
+
+	fmt.Printf("Rule successfully added: %v\n", rule.Name)
+	fmt.Printf("- %v\n", rule.Expression)
+
+	return nil
+}
+
+func handleRulesList(s *state, cmd command, user database.User) error {
+	userRules, err := s.database.GetRulesForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get rules: %v", err)
+	}
+
+	if len(userRules) == 0 {
+		fmt.Println("No rules found")
+		return nil
+	}
+
+	for _, rule := range userRules {
+		fmt.Printf("* %v\n", rule.Name)
+		fmt.Printf("  %v\n", rule.Expression)
+	}
+
+	return nil
+}
+
+func handleRulesRemove(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return fmt.Errorf("the rules remove command requires a name.\n%v", rulesUsage)
+	}
+
+	name := cmd.args[0]
+
+	deletedCount, err := s.database.DeleteRule(context.Background(), database.DeleteRuleParams{
+		UserID: user.ID,
+		Name:   name,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove rule: %v", err)
+	}
+	if deletedCount == 0 {
+		return fmt.Errorf("%w: %v", ErrRuleNotFound, name)
+	}
+
+	fmt.Printf("Rule removed: %v\n", name)
+
+	return nil
+}
+
+// handleRulesTest evaluates a saved rule (or an inline one) against a post
+// without applying any of its actions.
+func handleRulesTest(s *state, cmd command, user database.User) error {
+	if len(cmd.args) < 2 {
+		return fmt.Errorf("the rules test command requires a rule and a post ID.\n%v", rulesUsage)
+	}
+
+	ruleArg := strings.Join(cmd.args[:len(cmd.args)-1], " ")
+	postIDArg := cmd.args[len(cmd.args)-1]
+
+	expression := ruleArg
+	savedRule, err := s.database.FindRuleByName(context.Background(), database.FindRuleByNameParams{
+		UserID: user.ID,
+		Name:   ruleArg,
+	})
+	if err == nil {
+		expression = savedRule.Expression
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to find rule: %v", err)
+	}
+
+	rule, err := rules.Parse(expression)
+	if err != nil {
+		return err
+	}
+
+	post, err := findPostForUser(s, postIDArg, user.ID)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Rule: %v\n", expression)
+	fmt.Printf("Post: %v\n", post.Title)
+
+	target := rules.Post{
+		FeedName:    post.FeedName,
+		FeedURL:     post.FeedUrl,
+		URL:         post.Url,
+		Title:       post.Title,
+		Description: post.Description,
+		Content:     post.Content,
+		Author:      post.Author,
+		Categories:  post.Categories,
+	}
+
+	if !rule.Match(target) {
+		fmt.Println("Result: no match, nothing would happen")
+		return nil
+	}
+
+	fmt.Println("Result: match, the following actions would be applied")
+	for _, action := range rule.Actions {
+		fmt.Printf("- %v\n", action)
+	}
+
+	return nil
+}
+
+func handleTags(s *state, cmd command, user database.User) error {
+	tags, err := s.database.GetTagsForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get tags: %v", err)
+	}
+
+	if len(tags) == 0 {
+		fmt.Println("No tags found")
+		return nil
+	}
+
+	for _, tag := range tags {
+		fmt.Printf("- %v (%v)\n", tag.Tag, tag.PostCount)
+	}
+
+	return nil
+}
+
+// applyRules evaluates the rules of every user following the feed against a
+// newly created post and applies the actions of the matching ones.
+func applyRules(s *state, feed database.Feed, post database.Post) error {
+	feedRules, err := s.database.GetRulesForFeed(context.Background(), feed.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get rules for feed: %v", err)
+	}
+
+	target := rules.Post{
+		FeedName:    feed.Name,
+		FeedURL:     feed.Url,
+		URL:         post.Url,
+		Title:       post.Title,
+		Description: post.Description,
+		Content:     post.Content,
+		Author:      post.Author,
+		Categories:  post.Categories,
+	}
+
+	for _, savedRule := range feedRules {
+		rule, err := rules.Parse(savedRule.Expression)
+		if err != nil {
+			slog.Warn("skipping invalid rule", "rule", savedRule.Name, "user_id", savedRule.UserID, "error", err)
+			continue
+		}
+
+		if !rule.Match(target) {
+			continue
+		}
+
+		if err := applyRuleActions(s, savedRule.UserID, post.ID, rule.Actions); err != nil {
+			return fmt.Errorf("failed to apply rule %q: %v", savedRule.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func applyRuleActions(s *state, userID uuid.UUID, postID uuid.UUID, actions []rules.Action) error {
+	for _, action := range actions {
+		var err error
+
+		switch action.Kind {
+		case rules.ActionTag:
+			err = s.database.TagPost(context.Background(), database.TagPostParams{
+				UserID:    userID,
+				PostID:    postID,
+				Tag:       action.Tag,
+				CreatedAt: time.Now(),
+			})
+		case rules.ActionStar:
+			_, err = s.database.StarPost(context.Background(), database.StarPostParams{
+				UserID:    userID,
+				PostID:    postID,
+				CreatedAt: time.Now(),
+				UpdatedAt: time.Now(),
+			})
+		case rules.ActionMarkRead:
+			err = s.database.MarkPostRead(context.Background(), database.MarkPostReadParams{
+				UserID: userID,
+				PostID: postID,
+				ReadAt: time.Now(),
+			})
+		}
+
+		if err != nil {
+			return fmt.Errorf("%v: %v", action, err)
+		}
+	}
+
+	return nil
+}
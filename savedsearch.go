@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/database"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var ErrSavedSearchNotFound = errors.New("saved search not found")
+var ErrSavedSearchExists = errors.New("saved search already exists")
+
+const savedSearchUsage = `Usage:
+  gator savedsearch add <query> [--name name] [--notify]
+  gator savedsearch list
+  gator savedsearch notify <name> on|off
+  gator savedsearch remove <name>`
+
+func findSavedSearch(s *state, userID uuid.UUID, name string) (database.SavedSearch, error) {
+	savedSearch, err := s.database.FindSavedSearchByName(context.Background(), database.FindSavedSearchByNameParams{
+		UserID: userID,
+		Name:   name,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return database.SavedSearch{}, fmt.Errorf("%w: %v", ErrSavedSearchNotFound, name)
+		}
+
+		return database.SavedSearch{}, fmt.Errorf("failed to find saved search: %v", err)
+	}
+
+	return savedSearch, nil
+}
+
+func handleSavedSearch(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return fmt.Errorf("the savedsearch command requires a sub command.\n%v", savedSearchUsage)
+	}
+
+	subCmd := command{
+		name: cmd.name + " " + cmd.args[0],
+		args: cmd.args[1:],
+	}
+
+	switch cmd.args[0] {
+	case "add":
+		return handleSavedSearchAdd(s, subCmd, user)
+	case "list":
+		return handleSavedSearchList(s, subCmd, user)
+	case "notify":
+		return handleSavedSearchNotify(s, subCmd, user)
+	case "remove":
+		return handleSavedSearchRemove(s, subCmd, user)
+	}
+
+	return fmt.Errorf("unknown savedsearch sub command: %v\n%v", cmd.args[0], savedSearchUsage)
+}
+
+func handleSavedSearchAdd(s *state, cmd command, user database.User) error {
+	flags := newFlagSet(cmd.name)
+	name := flags.String("name", "", "name of the saved search (defaults to the query)")
+	notify := flags.Bool("notify", false, "notify when new matching posts are aggregated")
+
+	queryWords, err := parseFlags(flags, cmd.args)
+	if err != nil {
+		return fmt.Errorf("%v\n%v", err, savedSearchUsage)
+	}
+	if len(queryWords) == 0 {
+		return fmt.Errorf("the savedsearch add command requires a query.\n%v", savedSearchUsage)
+	}
+
+	query := strings.Join(queryWords, " ")
+	if *name == "" {
+		*name = query
+	}
+
+	savedSearch, err := s.database.CreateSavedSearch(context.Background(), database.CreateSavedSearchParams{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		Name:      *name,
+		Query:     query,
+		Notify:    *notify,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	})
+	if err != nil {
+		var pqErr *pq.Error
+
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return fmt.Errorf("%w: %v", ErrSavedSearchExists, *name)
+		}
+
+		return fmt.Errorf("failed to save search: %v", err)
+	}
+
+	fmt.Printf("Search saved: %v\n", savedSearch.Name)
+	fmt.Printf("- Query: %v\n", savedSearch.Query)
+	fmt.Printf("- Notify: %v\n", savedSearch.Notify)
+	fmt.Printf("Browse it with: gator browse --saved %q\n", savedSearch.Name)
+
+	return nil
+}
+
+func handleSavedSearchList(s *state, cmd command, user database.User) error {
+	savedSearches, err := s.database.GetSavedSearchesForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get saved searches: %v", err)
+	}
+
+	if len(savedSearches) == 0 {
+		fmt.Println("No saved searches found")
+		return nil
+	}
+
+	fmt.Printf("Saved searches\n")
+	fmt.Printf("--------------------------------\n")
+	for _, savedSearch := range savedSearches {
+		fmt.Printf("- Name:   %v\n", savedSearch.Name)
+		fmt.Printf("- Query:  %v\n", savedSearch.Query)
+		fmt.Printf("- Unread: %v\n", savedSearch.UnreadCount)
+		fmt.Printf("- Notify: %v\n", savedSearch.Notify)
+		fmt.Printf("--------------------------------\n")
+	}
+
+	return nil
+}
+
+func handleSavedSearchNotify(s *state, cmd command, user database.User) error {
+	if len(cmd.args) < 2 || (cmd.args[1] != "on" && cmd.args[1] != "off") {
+		return fmt.Errorf("the savedsearch notify command requires a name and on|off.\n%v", savedSearchUsage)
+	}
+
+	name := cmd.args[0]
+	notify := cmd.args[1] == "on"
+
+	updatedCount, err := s.database.SetSavedSearchNotify(context.Background(), database.SetSavedSearchNotifyParams{
+		Notify:    notify,
+		UpdatedAt: time.Now(),
+		UserID:    user.ID,
+		Name:      name,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update saved search: %v", err)
+	}
+	if updatedCount == 0 {
+		return fmt.Errorf("%w: %v", ErrSavedSearchNotFound, name)
+	}
+
+	fmt.Printf("Notifications for %v turned %v\n", name, cmd.args[1])
+
+	return nil
+}
+
+func handleSavedSearchRemove(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return fmt.Errorf("the savedsearch remove command requires a name.\n%v", savedSearchUsage)
+	}
+
+	name := cmd.args[0]
+
+	deletedCount, err := s.database.DeleteSavedSearch(context.Background(), database.DeleteSavedSearchParams{
+		UserID: user.ID,
+		Name:   name,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove saved search: %v", err)
+	}
+	if deletedCount == 0 {
+		return fmt.Errorf("%w: %v", ErrSavedSearchNotFound, name)
+	}
+
+	fmt.Printf("Saved search removed: %v\n", name)
+
+	return nil
+}
+
+// notifySavedSearchMatches prints a notification for every saved search with
+// notifications turned on which matches the newly aggregated post.
+func notifySavedSearchMatches(s *state, post database.Post) error {
+	matches, err := s.database.GetSavedSearchMatchesForPost(context.Background(), post.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, match := range matches {
+		fmt.Printf("🔔 [%v] New post matching saved search %q: %v\n", match.UserName, match.Name, post.Title)
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/database"
+	"golang.org/x/term"
+)
+
+const (
+	defaultSearchLimit = 10
+	headlineStart      = "[["
+	headlineStop       = "]]"
+	ansiHighlight      = "\033[1;33m"
+	ansiReset          = "\033[0m"
+)
+
+const searchUsage = "Usage: gator search <query> [--limit n] [--page n] [--show-filtered]"
+
+var htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// highlight replaces the markers inserted by ts_headline with ANSI colours
+// when writing to a terminal, and with plain asterisks otherwise.
+func highlight(text string, colored bool) string {
+	start, stop := "*", "*"
+	if colored {
+		start, stop = ansiHighlight, ansiReset
+	}
+
+	return strings.NewReplacer(headlineStart, start, headlineStop, stop).Replace(text)
+}
+
+// highlightLines highlights the lines of a wrapped snippet. A highlighted
+// phrase may span a line break, so it is closed at the end of the line and
+// reopened on the next one instead of colouring the indentation in between.
+func highlightLines(lines []string, colored bool) []string {
+	highlighted := make([]string, 0, len(lines))
+	open := false
+
+	for _, line := range lines {
+		if open {
+			line = headlineStart + line
+		}
+
+		open = strings.LastIndex(line, headlineStart) > strings.LastIndex(line, headlineStop)
+		if open {
+			line += headlineStop
+		}
+
+		highlighted = append(highlighted, highlight(line, colored))
+	}
+
+	return highlighted
+}
+
+func handleSearch(s *state, cmd command, user database.User) error {
+	flags := newFlagSet("search")
+	limit := flags.Int("limit", defaultSearchLimit, "number of results to show")
+	page := flags.Int("page", 1, "page number (starting at 1)")
+	showFiltered := flags.Bool("show-filtered", false, "also show posts hidden by your filters")
+
+	queryWords, err := parseFlags(flags, cmd.args)
+	if err != nil {
+		return fmt.Errorf("%v\n%v", err, searchUsage)
+	}
+	if len(queryWords) == 0 {
+		return fmt.Errorf("the search command requires a query. %v", searchUsage)
+	}
+	if *limit <= 0 || *page <= 0 {
+		return fmt.Errorf("the limit and page must be positive numbers")
+	}
+
+	query := strings.Join(queryWords, " ")
+
+	results, err := s.database.SearchPostsForUser(context.Background(), database.SearchPostsForUserParams{
+		Query:        query,
+		UserID:       user.ID,
+		ShowFiltered: *showFiltered,
+		Limit:        int32(*limit),
+		Offset:       int32((*page - 1) * *limit),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to search posts: %v", err)
+	}
+
+	if len(results) == 0 {
+		fmt.Printf("No posts found matching: %v\n", query)
+		return nil
+	}
+
+	colored := term.IsTerminal(int(os.Stdout.Fd()))
+	width := terminalWidth()
+	indent := "   "
+
+	for i, result := range results {
+		publishedAtStr := "N/A"
+		if result.PublishedAt.Valid {
+			publishedAtStr = result.PublishedAt.Time.Format("02 January 2006 15:04")
+		}
+
+		title := highlight(sanitizeText(result.TitleHeadline), colored)
+		if result.IsFiltered {
+			title += " [filtered]"
+		}
+
+		fmt.Printf("%2d. %v\n", (*page-1)**limit+i+1, title)
+		fmt.Printf("%vFeed: %v | Published At: %v | Rank: %.3f\n", indent, result.FeedName, publishedAtStr, result.Rank)
+
+		snippet := sanitizeText(htmlTagPattern.ReplaceAllString(result.Snippet, " "))
+		for _, line := range highlightLines(wrapText(snippet, width-len(indent)), colored) {
+			fmt.Printf("%v%v\n", indent, line)
+		}
+
+		fmt.Printf("%vID: %v\n", indent, result.ID)
+		fmt.Printf("%vURL: %v\n", indent, result.Url)
+		fmt.Println()
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestHighlightLines(t *testing.T) {
+	tests := []struct {
+		name    string
+		lines   []string
+		colored bool
+		want    []string
+	}{
+		{
+			name:  "no highlight",
+			lines: []string{"plain text", "more text"},
+			want:  []string{"plain text", "more text"},
+		},
+		{
+			name:  "within a line",
+			lines: []string{"a [[go]] post", "about [[generics]]"},
+			want:  []string{"a *go* post", "about *generics*"},
+		},
+		{
+			name:  "spanning a line break",
+			lines: []string{"a [[go", "generics]] post"},
+			want:  []string{"a *go*", "*generics* post"},
+		},
+		{
+			name:  "spanning several lines",
+			lines: []string{"[[one", "two", "three]] four"},
+			want:  []string{"*one*", "*two*", "*three* four"},
+		},
+		{
+			name:  "closed then reopened on the same line",
+			lines: []string{"[[a]] b [[c", "d]]"},
+			want:  []string{"*a* b *c*", "*d*"},
+		},
+		{
+			name:    "colored",
+			lines:   []string{"a [[go", "generics]] post"},
+			colored: true,
+			want: []string{
+				"a " + ansiHighlight + "go" + ansiReset,
+				ansiHighlight + "generics" + ansiReset + " post",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := highlightLines(tt.lines, tt.colored)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("highlightLines(%q) = %q, want %q", tt.lines, got, tt.want)
+			}
+		})
+	}
+}
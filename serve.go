@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+const defaultServeAddr = "localhost:8080"
+
+const serveUsage = "Usage: gator serve [--addr host:port]"
+
+// handleServe runs the web UI, the REST API and the river feeds until it is
+// interrupted. API requests are authenticated with API keys, see
+// "gator apikey create".
+func handleServe(s *state, cmd command) error {
+	flags := newFlagSet(cmd.name)
+	addr := flags.String("addr", defaultServeAddr, "address to listen on")
+
+	if _, err := parseFlags(flags, cmd.args); err != nil {
+		return fmt.Errorf("%v\n%v", err, serveUsage)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           newServer(s),
+		ReadHeaderTimeout: 10 * time.Second,
+		ErrorLog:          slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
+	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.ListenAndServe()
+	}()
+
+	slog.Info("serving gator (web UI: /, API: /api, OpenAPI description: /api/openapi.yaml)", "url", "http://"+*addr)
+
+	select {
+	case err := <-serverErr:
+		return fmt.Errorf("failed to serve: %v", err)
+	case <-ctx.Done():
+	}
+
+	slog.Info("shutting down")
+
+	// Open requests get a few seconds to finish
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to shut down the server: %v", err)
+	}
+
+	return nil
+}
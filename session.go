@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/auth"
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/database"
+	"github.com/google/uuid"
+)
+
+var ErrNotLoggedIn = errors.New("you must be logged in to use this feature. Use: gator login <username> or gator register <username>")
+var ErrSessionRevoked = errors.New("your session has ended. Use: gator login <username>")
+var ErrPasswordRequired = errors.New("the user is password protected. Use: gator login <username>")
+var ErrInvalidPassword = errors.New("invalid password")
+
+// currentUser returns the logged in user. Users log in with a session token
+// stored in the config. Configs written before sessions existed only hold the
+// user name, which is still accepted for users without a password.
+func currentUser(s *state) (database.User, error) {
+	if s.config.SessionToken != nil && *s.config.SessionToken != "" {
+		tokenHash := auth.HashSessionToken(*s.config.SessionToken)
+
+		user, err := s.database.FindUserBySessionToken(context.Background(), database.FindUserBySessionTokenParams{
+			TokenHash: tokenHash,
+			Now:       time.Now(),
+		})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return database.User{}, ErrSessionRevoked
+			}
+
+			return database.User{}, fmt.Errorf("failed to find current user: %v", err)
+		}
+
+		err = s.database.TouchSession(context.Background(), database.TouchSessionParams{
+			LastUsedAt: time.Now(),
+			TokenHash:  tokenHash,
+		})
+		if err != nil {
+			return database.User{}, fmt.Errorf("failed to update session: %v", err)
+		}
+
+		return user, nil
+	}
+
+	if s.config.CurrentUserName == nil || *s.config.CurrentUserName == "" {
+		return database.User{}, ErrNotLoggedIn
+	}
+
+	user, err := s.database.FindUserByeName(context.Background(), *s.config.CurrentUserName)
+	if err != nil {
+		return database.User{}, fmt.Errorf("failed to find current user: %v", err)
+	}
+
+	if user.PasswordHash != "" {
+		return database.User{}, ErrPasswordRequired
+	}
+
+	return user, nil
+}
+
+// startSession creates a new session for the user and stores its token in
+// the config.
+func startSession(s *state, user database.User) error {
+	token, err := auth.NewSessionToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate session token: %v", err)
+	}
+
+	_, err = s.database.CreateSession(context.Background(), database.CreateSessionParams{
+		ID:         uuid.New(),
+		UserID:     user.ID,
+		TokenHash:  auth.HashSessionToken(token),
+		CreatedAt:  time.Now(),
+		LastUsedAt: time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create session: %v", err)
+	}
+
+	if err := s.config.SetSession(user.Name, token); err != nil {
+		return fmt.Errorf("failed to save session: %v", err)
+	}
+
+	return nil
+}
+
+// verifyPassword prompts for the password of the user, users without a
+// password are let through.
+func verifyPassword(user database.User, prompt string) error {
+	if user.PasswordHash == "" {
+		return nil
+	}
+
+	password, err := readPassword(prompt)
+	if err != nil {
+		return err
+	}
+
+	ok, err := auth.CheckPassword(password, user.PasswordHash)
+	if err != nil {
+		return fmt.Errorf("failed to check password: %v", err)
+	}
+	if !ok {
+		return ErrInvalidPassword
+	}
+
+	return nil
+}
+
+// setPassword stores the hash of the password, an empty password removes the
+// password of the user.
+func setPassword(s *state, user database.User, password string) error {
+	passwordHash := ""
+	if password != "" {
+		hash, err := auth.HashPassword(password)
+		if err != nil {
+			return fmt.Errorf("failed to hash password: %v", err)
+		}
+		passwordHash = hash
+	}
+
+	err := s.database.SetUserPassword(context.Background(), database.SetUserPasswordParams{
+		PasswordHash: passwordHash,
+		UpdatedAt:    time.Now(),
+		ID:           user.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set password: %v", err)
+	}
+
+	return nil
+}
+
+func handleLogout(s *state, cmd command) error {
+	if s.config.SessionToken != nil && *s.config.SessionToken != "" {
+		err := s.database.DeleteSession(context.Background(), auth.HashSessionToken(*s.config.SessionToken))
+		if err != nil {
+			return fmt.Errorf("failed to revoke session: %v", err)
+		}
+	}
+
+	if err := s.config.ClearSession(); err != nil {
+		return fmt.Errorf("failed to log out: %v", err)
+	}
+
+	fmt.Println("Logged out")
+
+	return nil
+}
+
+// handlePasswd sets, changes or (with an empty new password) removes the
+// password of the current user. All sessions of the user are revoked, only
+// the current one is renewed.
+func handlePasswd(s *state, cmd command, user database.User) error {
+	if err := verifyPassword(user, "Current password: "); err != nil {
+		return err
+	}
+
+	password, err := readNewPassword("New password (leave empty to remove the password): ")
+	if err != nil {
+		return err
+	}
+
+	if err := setPassword(s, user, password); err != nil {
+		return err
+	}
+
+	if err := s.database.DeleteSessionsForUser(context.Background(), user.ID); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %v", err)
+	}
+
+	if err := startSession(s, user); err != nil {
+		return err
+	}
+
+	if password == "" {
+		fmt.Println("Password removed")
+	} else {
+		fmt.Println("Password changed, other sessions have been logged out")
+	}
+
+	return nil
+}
@@ -0,0 +1,26 @@
+-- name: CreateAPIKey :one
+INSERT INTO api_keys (id, user_id, key_hash, created_at, label, scope, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: FindUserByAPIKey :one
+SELECT users.*
+FROM api_keys
+INNER JOIN users ON users.id = api_keys.user_id
+WHERE api_keys.key_hash = sqlc.arg('key_hash')
+  AND (api_keys.expires_at IS NULL OR api_keys.expires_at > sqlc.arg('now')::timestamptz)
+LIMIT 1;
+
+-- name: TouchAPIKey :one
+UPDATE api_keys SET last_used_at = $1
+WHERE key_hash = $2
+RETURNING scope;
+
+-- name: GetAPIKeysForUser :many
+SELECT * FROM api_keys
+WHERE user_id = $1
+ORDER BY created_at;
+
+-- name: DeleteAPIKey :execrows
+DELETE FROM api_keys
+WHERE id = $1 AND user_id = $2;
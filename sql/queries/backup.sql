@@ -0,0 +1,141 @@
+-- name: BackupUsers :many
+SELECT * FROM users ORDER BY created_at ASC, id ASC;
+
+-- name: BackupFeeds :many
+SELECT * FROM feeds ORDER BY created_at ASC, id ASC;
+
+-- name: BackupFeedFollows :many
+SELECT * FROM feed_follows ORDER BY created_at ASC, id ASC;
+
+-- name: BackupPosts :many
+SELECT id, feed_id, title, url, description, published_at, created_at, updated_at, author, content, categories
+FROM posts
+ORDER BY created_at ASC, id ASC;
+
+-- name: BackupPostReads :many
+SELECT * FROM post_reads ORDER BY read_at ASC;
+
+-- name: BackupBookmarks :many
+SELECT * FROM bookmarks ORDER BY created_at ASC;
+
+-- name: BackupSavedSearches :many
+SELECT * FROM saved_searches ORDER BY created_at ASC, id ASC;
+
+-- name: BackupPostFilters :many
+SELECT * FROM post_filters ORDER BY created_at ASC, id ASC;
+
+-- name: BackupRules :many
+SELECT * FROM rules ORDER BY created_at ASC, id ASC;
+
+-- name: BackupPostTags :many
+SELECT * FROM post_tags ORDER BY created_at ASC;
+
+-- name: BackupFolders :many
+SELECT * FROM folders ORDER BY created_at ASC, id ASC;
+
+-- name: BackupFolderFeeds :many
+SELECT * FROM folder_feeds ORDER BY created_at ASC;
+
+-- name: BackupWebhooks :many
+SELECT * FROM webhooks ORDER BY created_at ASC, id ASC;
+
+-- name: BackupDigestSubscriptions :many
+SELECT * FROM digest_subscriptions ORDER BY created_at ASC, user_id ASC;
+
+-- name: BackupDigests :many
+SELECT * FROM digests ORDER BY sent_at ASC, id ASC;
+
+-- name: BackupDigestPosts :many
+SELECT * FROM digest_posts ORDER BY digest_id ASC, post_id ASC;
+
+-- name: RestoreUser :one
+INSERT INTO users (id, name, created_at, updated_at, password_hash, role)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+RETURNING id, (xmax = 0)::boolean AS inserted;
+
+-- name: RestoreFeed :one
+INSERT INTO feeds (id, user_id, url, name, created_at, last_fetched_at, site_url)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (url) DO UPDATE SET url = EXCLUDED.url
+RETURNING id, (xmax = 0)::boolean AS inserted;
+
+-- name: RestoreFeedFollow :execrows
+INSERT INTO feed_follows (id, user_id, feed_id, created_at, notify)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT DO NOTHING;
+
+-- name: RestorePost :one
+INSERT INTO posts (id, feed_id, title, url, description, published_at, created_at, updated_at, author, content, categories)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+ON CONFLICT (url) DO UPDATE SET url = EXCLUDED.url
+RETURNING id, (xmax = 0)::boolean AS inserted;
+
+-- name: RestorePostRead :execrows
+INSERT INTO post_reads (user_id, post_id, read_at)
+VALUES ($1, $2, $3)
+ON CONFLICT DO NOTHING;
+
+-- name: RestoreBookmark :execrows
+INSERT INTO bookmarks (user_id, post_id, note, created_at, updated_at, post_title, post_url, post_description, post_author, post_published_at, feed_name)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+ON CONFLICT DO NOTHING;
+
+-- name: RestoreSavedSearch :execrows
+INSERT INTO saved_searches (id, user_id, name, query, notify, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT DO NOTHING;
+
+-- name: RestorePostFilter :execrows
+INSERT INTO post_filters (id, user_id, feed_id, kind, pattern, created_at)
+SELECT sqlc.arg('id')::uuid, sqlc.arg('user_id')::uuid, sqlc.narg('feed_id')::uuid, sqlc.arg('kind')::text, sqlc.arg('pattern')::text, sqlc.arg('created_at')::timestamptz
+WHERE NOT EXISTS (
+  SELECT 1 FROM post_filters
+  WHERE user_id = sqlc.arg('user_id')::uuid
+    AND feed_id IS NOT DISTINCT FROM sqlc.narg('feed_id')::uuid
+    AND kind = sqlc.arg('kind')::text
+    AND pattern = sqlc.arg('pattern')::text
+)
+ON CONFLICT DO NOTHING;
+
+-- name: RestoreRule :execrows
+INSERT INTO rules (id, user_id, name, expression, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT DO NOTHING;
+
+-- name: RestorePostTag :execrows
+INSERT INTO post_tags (user_id, post_id, tag, created_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT DO NOTHING;
+
+-- name: RestoreFolder :one
+INSERT INTO folders (id, user_id, name, created_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id, name) DO UPDATE SET name = EXCLUDED.name
+RETURNING id, (xmax = 0)::boolean AS inserted;
+
+-- name: RestoreFolderFeed :execrows
+INSERT INTO folder_feeds (folder_id, feed_id, created_at)
+VALUES ($1, $2, $3)
+ON CONFLICT DO NOTHING;
+
+-- name: RestoreWebhook :execrows
+INSERT INTO webhooks (id, user_id, name, url, format, secret, feed_id, tag, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+ON CONFLICT DO NOTHING;
+
+-- name: RestoreDigestSubscription :execrows
+INSERT INTO digest_subscriptions (user_id, email, frequency, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT DO NOTHING;
+
+-- name: RestoreDigest :one
+INSERT INTO digests (id, user_id, email, post_count, sent_at)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (id) DO UPDATE SET id = EXCLUDED.id
+RETURNING id, (xmax = 0)::boolean AS inserted;
+
+-- name: RestoreDigestPost :execrows
+INSERT INTO digest_posts (digest_id, post_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
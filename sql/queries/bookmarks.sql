@@ -0,0 +1,51 @@
+-- name: StarPost :one
+INSERT INTO bookmarks (user_id, post_id, note, created_at, updated_at, post_title, post_url, post_description, post_author, post_published_at, feed_name)
+SELECT
+  sqlc.arg('user_id')::uuid,
+  posts.id,
+  COALESCE(sqlc.narg('note')::text, ''),
+  sqlc.arg('created_at')::timestamptz,
+  sqlc.arg('updated_at')::timestamptz,
+  posts.title,
+  posts.url,
+  posts.description,
+  posts.author,
+  posts.published_at,
+  feeds.name
+FROM posts
+INNER JOIN feeds ON feeds.id = posts.feed_id
+WHERE posts.id = sqlc.arg('post_id')::uuid
+ON CONFLICT (user_id, post_id) DO UPDATE SET
+  note = CASE WHEN sqlc.narg('note')::text IS NULL THEN bookmarks.note ELSE EXCLUDED.note END,
+  updated_at = EXCLUDED.updated_at,
+  post_title = EXCLUDED.post_title,
+  post_url = EXCLUDED.post_url,
+  post_description = EXCLUDED.post_description,
+  post_author = EXCLUDED.post_author,
+  post_published_at = EXCLUDED.post_published_at,
+  feed_name = EXCLUDED.feed_name
+RETURNING *;
+
+-- name: UnstarPost :execrows
+DELETE FROM bookmarks
+WHERE user_id = $1 AND post_id = $2;
+
+-- name: GetBookmarksForUser :many
+SELECT
+  bookmarks.post_id AS id,
+  COALESCE(posts.title, bookmarks.post_title) AS title,
+  COALESCE(posts.url, bookmarks.post_url) AS url,
+  COALESCE(posts.description, bookmarks.post_description) AS description,
+  COALESCE(posts.author, bookmarks.post_author) AS author,
+  CASE WHEN posts.id IS NULL THEN bookmarks.post_published_at ELSE posts.published_at END AS published_at,
+  COALESCE(feeds.name, bookmarks.feed_name) AS feed_name,
+  bookmarks.note,
+  bookmarks.created_at AS starred_at,
+  (post_reads.post_id IS NOT NULL)::boolean AS is_read
+FROM bookmarks
+LEFT JOIN posts ON posts.id = bookmarks.post_id
+LEFT JOIN feeds ON feeds.id = posts.feed_id
+LEFT JOIN post_reads ON post_reads.post_id = bookmarks.post_id AND post_reads.user_id = bookmarks.user_id
+WHERE bookmarks.user_id = $1
+ORDER BY bookmarks.created_at DESC
+LIMIT $2;
@@ -0,0 +1,74 @@
+-- name: SetDigestSubscription :one
+INSERT INTO digest_subscriptions (user_id, email, frequency, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (user_id) DO UPDATE SET
+  email = EXCLUDED.email,
+  frequency = EXCLUDED.frequency,
+  updated_at = EXCLUDED.updated_at
+RETURNING *;
+
+-- name: FindDigestSubscription :one
+SELECT * FROM digest_subscriptions WHERE user_id = $1;
+
+-- name: DeleteDigestSubscription :execrows
+DELETE FROM digest_subscriptions WHERE user_id = $1;
+
+-- name: GetDigestSubscriptions :many
+SELECT
+  digest_subscriptions.*,
+  users.name AS user_name,
+  last_digests.last_sent_at
+FROM digest_subscriptions
+INNER JOIN users ON users.id = digest_subscriptions.user_id
+LEFT JOIN (
+  SELECT digests.user_id, MAX(digests.sent_at) AS last_sent_at
+  FROM digests
+  GROUP BY digests.user_id
+) AS last_digests ON last_digests.user_id = digest_subscriptions.user_id
+WHERE sqlc.narg('user_name')::text IS NULL OR users.name = sqlc.narg('user_name')::text
+ORDER BY users.name ASC;
+
+-- name: GetLastDigest :one
+SELECT * FROM digests
+WHERE user_id = $1
+ORDER BY sent_at DESC
+LIMIT 1;
+
+-- name: GetDigestPosts :many
+SELECT
+  posts.id,
+  posts.title,
+  posts.url,
+  posts.description,
+  posts.author,
+  posts.published_at,
+  posts.created_at,
+  feeds.name AS feed_name
+FROM posts
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+INNER JOIN feeds ON feeds.id = posts.feed_id
+WHERE feed_follows.user_id = sqlc.arg('user_id')
+  AND posts.created_at > sqlc.arg('since')
+  AND NOT EXISTS (
+    SELECT 1
+    FROM post_reads
+    WHERE post_reads.user_id = feed_follows.user_id AND post_reads.post_id = posts.id
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM digest_posts
+    INNER JOIN digests ON digests.id = digest_posts.digest_id
+    WHERE digests.user_id = feed_follows.user_id AND digest_posts.post_id = posts.id
+  )
+  AND NOT post_is_filtered(feed_follows.user_id, posts.id)
+ORDER BY COALESCE(posts.published_at, posts.created_at) DESC, posts.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: CreateDigest :one
+INSERT INTO digests (id, user_id, email, post_count, sent_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: CreateDigestPost :exec
+INSERT INTO digest_posts (digest_id, post_id)
+VALUES ($1, $2);
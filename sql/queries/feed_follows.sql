@@ -19,8 +19,17 @@ LIMIT 1;
 -- name: GetFeedFollowsForUser :many
 SELECT 
   feed_follows.id AS feed_follow_id,
+  feeds.id AS feed_id,
   feeds.name as feed_name,
-  feeds.url as feed_url
+  feeds.url as feed_url,
+  feeds.site_url as feed_site_url,
+  feed_follows.notify,
+  (
+    SELECT COUNT(*)
+    FROM posts
+    LEFT JOIN post_reads ON post_reads.post_id = posts.id AND post_reads.user_id = feed_follows.user_id
+    WHERE posts.feed_id = feeds.id AND post_reads.post_id IS NULL
+  ) AS unread_count
 FROM feed_follows
 INNER JOIN feeds ON feed_follows.feed_id = feeds.id
 WHERE feed_follows.user_id = $1 
@@ -30,3 +39,29 @@ ORDER BY feed_follows.created_at ASC;
 DELETE FROM feed_follows
 WHERE
   user_id = $1 AND feed_id = $2;
+
+-- name: SetFeedFollowNotify :exec
+UPDATE feed_follows
+SET notify = $1
+WHERE user_id = $2 AND feed_id = $3;
+
+-- name: GetNotifyFollowersForPost :many
+SELECT users.name
+FROM feed_follows
+INNER JOIN users ON users.id = feed_follows.user_id
+INNER JOIN posts ON posts.feed_id = feed_follows.feed_id
+WHERE posts.id = $1
+  AND feed_follows.notify
+  AND NOT post_is_filtered(feed_follows.user_id, posts.id)
+ORDER BY users.name ASC;
+
+-- name: FollowFeedIfNotFollowed :execrows
+INSERT INTO feed_follows (id, user_id, feed_id, created_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id, feed_id) DO NOTHING;
+
+-- name: FindFollowedFeedByUrl :one
+SELECT feeds.*
+FROM feeds
+INNER JOIN feed_follows ON feed_follows.feed_id = feeds.id
+WHERE feed_follows.user_id = $1 AND feeds.url = $2;
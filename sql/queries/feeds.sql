@@ -20,3 +20,21 @@ FROM feeds
 WHERE last_fetched_at IS NULL
 ORDER BY created_at ASC, last_fetched_at IS NULL DESC
 LIMIT 1;
+
+-- name: SetFeedSiteUrl :exec
+UPDATE feeds SET site_url = $1 WHERE id = $2;
+
+-- name: RenameFeed :exec
+UPDATE feeds SET name = $1 WHERE id = $2;
+
+-- name: SetFeedUrl :exec
+UPDATE feeds SET url = $1, last_fetched_at = NULL WHERE id = $2;
+
+-- name: TransferFeed :exec
+UPDATE feeds SET user_id = $1 WHERE id = $2;
+
+-- name: DeleteFeed :exec
+DELETE FROM feeds WHERE id = $1;
+
+-- name: CountFeedFollowers :one
+SELECT COUNT(*) FROM feed_follows WHERE feed_id = $1;
@@ -0,0 +1,42 @@
+-- name: CreateFolder :one
+INSERT INTO folders (id, user_id, name, created_at)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: FindFolderByName :one
+SELECT * FROM folders WHERE user_id = $1 AND name = $2 LIMIT 1;
+
+-- name: GetFoldersForUser :many
+SELECT
+  folders.*,
+  (SELECT COUNT(*) FROM folder_feeds WHERE folder_feeds.folder_id = folders.id) AS feed_count
+FROM folders
+WHERE folders.user_id = $1
+ORDER BY folders.name ASC;
+
+-- name: DeleteFolder :execrows
+DELETE FROM folders WHERE user_id = $1 AND name = $2;
+
+-- name: AddFeedToFolder :exec
+INSERT INTO folder_feeds (folder_id, feed_id, created_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (folder_id, feed_id) DO NOTHING;
+
+-- name: RemoveFeedFromFolder :execrows
+DELETE FROM folder_feeds WHERE folder_id = $1 AND feed_id = $2;
+
+-- name: RemoveFeedFromUserFolders :exec
+DELETE FROM folder_feeds
+USING folders
+WHERE folders.id = folder_feeds.folder_id
+  AND folders.user_id = $1
+  AND folder_feeds.feed_id = $2;
+
+-- name: GetFolderFeedsForUser :many
+SELECT
+  folders.name AS folder_name,
+  folder_feeds.feed_id
+FROM folder_feeds
+INNER JOIN folders ON folders.id = folder_feeds.folder_id
+WHERE folders.user_id = $1
+ORDER BY folders.name ASC;
@@ -0,0 +1,19 @@
+-- name: CreatePostFilter :one
+INSERT INTO post_filters (id, user_id, feed_id, kind, pattern, created_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetPostFiltersForUser :many
+SELECT
+  post_filters.*,
+  feeds.name AS feed_name
+FROM post_filters
+LEFT JOIN feeds ON feeds.id = post_filters.feed_id
+WHERE post_filters.user_id = $1
+ORDER BY post_filters.created_at ASC;
+
+-- name: DeletePostFilter :execrows
+DELETE FROM post_filters WHERE id = $1 AND user_id = $2;
+
+-- name: CheckRegexPattern :one
+SELECT (''::text ~* sqlc.arg('pattern')::text)::boolean AS matches_empty;
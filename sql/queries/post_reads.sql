@@ -0,0 +1,18 @@
+-- name: MarkPostRead :exec
+INSERT INTO post_reads (user_id, post_id, read_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: MarkPostUnread :execrows
+DELETE FROM post_reads
+WHERE user_id = $1 AND post_id = $2;
+
+-- name: MarkAllPostsRead :execrows
+INSERT INTO post_reads (user_id, post_id, read_at)
+SELECT feed_follows.user_id, posts.id, sqlc.arg('read_at')::timestamptz
+FROM posts
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+INNER JOIN feeds ON feeds.id = posts.feed_id
+WHERE feed_follows.user_id = sqlc.arg('user_id')
+  AND (sqlc.narg('feed')::text IS NULL OR LOWER(feeds.name) = LOWER(sqlc.narg('feed')::text) OR feeds.url = sqlc.narg('feed')::text)
+ON CONFLICT (user_id, post_id) DO NOTHING;
@@ -0,0 +1,11 @@
+-- name: TagPost :exec
+INSERT INTO post_tags (user_id, post_id, tag, created_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id, post_id, tag) DO NOTHING;
+
+-- name: GetTagsForUser :many
+SELECT tag, COUNT(*) AS post_count
+FROM post_tags
+WHERE user_id = $1
+GROUP BY tag
+ORDER BY tag ASC;
@@ -1,12 +1,178 @@
 -- name: CreatePost :one
-INSERT INTO posts (id, feed_id, title, url, description, published_at, created_at, updated_at)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+INSERT INTO posts (id, feed_id, title, url, description, published_at, created_at, updated_at, author, content, categories)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 RETURNING *;
 
--- name: GetPostForUser :many
-SELECT posts.*
+-- name: FindPostForUser :one
+SELECT
+  posts.id,
+  posts.feed_id,
+  posts.title,
+  posts.url,
+  posts.description,
+  posts.published_at,
+  posts.created_at,
+  posts.updated_at,
+  posts.author,
+  posts.content,
+  posts.categories,
+  feeds.name AS feed_name,
+  feeds.url AS feed_url
 FROM posts
 INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
-WHERE feed_follows.user_id = $1
-ORDER BY posts.created_at DESC
-LIMIT $2;
+INNER JOIN feeds ON feeds.id = posts.feed_id
+WHERE posts.id = $1 AND feed_follows.user_id = $2
+LIMIT 1;
+
+-- name: GetPostsForUser :many
+SELECT
+  posts.id,
+  posts.feed_id,
+  posts.title,
+  posts.url,
+  posts.description,
+  posts.published_at,
+  posts.created_at,
+  posts.updated_at,
+  posts.author,
+  posts.content,
+  posts.categories,
+  feeds.name AS feed_name,
+  feeds.url AS feed_url,
+  (post_reads.post_id IS NOT NULL)::boolean AS is_read,
+  (bookmarks.post_id IS NOT NULL)::boolean AS is_starred,
+  post_is_filtered(feed_follows.user_id, posts.id)::boolean AS is_filtered,
+  ARRAY(
+    SELECT post_tags.tag FROM post_tags
+    WHERE post_tags.post_id = posts.id AND post_tags.user_id = feed_follows.user_id
+    ORDER BY post_tags.tag
+  )::text[] AS tags,
+  COALESCE(posts.published_at, posts.created_at) AS sort_key
+FROM posts
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+INNER JOIN feeds ON feeds.id = posts.feed_id
+LEFT JOIN post_reads ON post_reads.post_id = posts.id AND post_reads.user_id = feed_follows.user_id
+LEFT JOIN bookmarks ON bookmarks.post_id = posts.id AND bookmarks.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = sqlc.arg('user_id')
+  AND (sqlc.narg('feed')::text IS NULL OR LOWER(feeds.name) = LOWER(sqlc.narg('feed')::text) OR feeds.url = sqlc.narg('feed')::text)
+  AND (sqlc.narg('author')::text IS NULL OR posts.author ILIKE '%' || sqlc.narg('author')::text || '%')
+  AND (NOT sqlc.arg('unread_only')::boolean OR post_reads.post_id IS NULL)
+  AND (sqlc.narg('query')::text IS NULL OR posts.search_vector @@ websearch_to_tsquery('english', sqlc.narg('query')::text))
+  AND (
+    sqlc.narg('folder')::text IS NULL
+    OR EXISTS (
+      SELECT 1 FROM folder_feeds
+      INNER JOIN folders ON folders.id = folder_feeds.folder_id
+      WHERE folders.user_id = feed_follows.user_id AND LOWER(folders.name) = LOWER(sqlc.narg('folder')::text) AND folder_feeds.feed_id = posts.feed_id
+    )
+  )
+  AND (
+    sqlc.narg('tag')::text IS NULL
+    OR EXISTS (
+      SELECT 1 FROM post_tags
+      WHERE post_tags.post_id = posts.id AND post_tags.user_id = feed_follows.user_id AND post_tags.tag = LOWER(sqlc.narg('tag')::text)
+    )
+  )
+  AND (sqlc.arg('show_filtered')::boolean OR NOT post_is_filtered(feed_follows.user_id, posts.id))
+  AND (sqlc.narg('since')::timestamptz IS NULL OR COALESCE(posts.published_at, posts.created_at) >= sqlc.narg('since')::timestamptz)
+  AND (sqlc.narg('before')::timestamptz IS NULL OR COALESCE(posts.published_at, posts.created_at) < sqlc.narg('before')::timestamptz)
+  AND (
+    sqlc.narg('cursor_time')::timestamptz IS NULL
+    OR (COALESCE(posts.published_at, posts.created_at), posts.id) < (sqlc.narg('cursor_time')::timestamptz, sqlc.narg('cursor_id')::uuid)
+  )
+ORDER BY COALESCE(posts.published_at, posts.created_at) DESC, posts.id DESC
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+-- name: GetPostsForUserByFetched :many
+SELECT
+  posts.id,
+  posts.feed_id,
+  posts.title,
+  posts.url,
+  posts.description,
+  posts.published_at,
+  posts.created_at,
+  posts.updated_at,
+  posts.author,
+  posts.content,
+  posts.categories,
+  feeds.name AS feed_name,
+  feeds.url AS feed_url,
+  (post_reads.post_id IS NOT NULL)::boolean AS is_read,
+  (bookmarks.post_id IS NOT NULL)::boolean AS is_starred,
+  post_is_filtered(feed_follows.user_id, posts.id)::boolean AS is_filtered,
+  ARRAY(
+    SELECT post_tags.tag FROM post_tags
+    WHERE post_tags.post_id = posts.id AND post_tags.user_id = feed_follows.user_id
+    ORDER BY post_tags.tag
+  )::text[] AS tags,
+  posts.created_at AS sort_key
+FROM posts
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+INNER JOIN feeds ON feeds.id = posts.feed_id
+LEFT JOIN post_reads ON post_reads.post_id = posts.id AND post_reads.user_id = feed_follows.user_id
+LEFT JOIN bookmarks ON bookmarks.post_id = posts.id AND bookmarks.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = sqlc.arg('user_id')
+  AND (sqlc.narg('feed')::text IS NULL OR LOWER(feeds.name) = LOWER(sqlc.narg('feed')::text) OR feeds.url = sqlc.narg('feed')::text)
+  AND (sqlc.narg('author')::text IS NULL OR posts.author ILIKE '%' || sqlc.narg('author')::text || '%')
+  AND (NOT sqlc.arg('unread_only')::boolean OR post_reads.post_id IS NULL)
+  AND (sqlc.narg('query')::text IS NULL OR posts.search_vector @@ websearch_to_tsquery('english', sqlc.narg('query')::text))
+  AND (
+    sqlc.narg('folder')::text IS NULL
+    OR EXISTS (
+      SELECT 1 FROM folder_feeds
+      INNER JOIN folders ON folders.id = folder_feeds.folder_id
+      WHERE folders.user_id = feed_follows.user_id AND LOWER(folders.name) = LOWER(sqlc.narg('folder')::text) AND folder_feeds.feed_id = posts.feed_id
+    )
+  )
+  AND (
+    sqlc.narg('tag')::text IS NULL
+    OR EXISTS (
+      SELECT 1 FROM post_tags
+      WHERE post_tags.post_id = posts.id AND post_tags.user_id = feed_follows.user_id AND post_tags.tag = LOWER(sqlc.narg('tag')::text)
+    )
+  )
+  AND (sqlc.arg('show_filtered')::boolean OR NOT post_is_filtered(feed_follows.user_id, posts.id))
+  AND (sqlc.narg('since')::timestamptz IS NULL OR posts.created_at >= sqlc.narg('since')::timestamptz)
+  AND (sqlc.narg('before')::timestamptz IS NULL OR posts.created_at < sqlc.narg('before')::timestamptz)
+  AND (
+    sqlc.narg('cursor_time')::timestamptz IS NULL
+    OR (posts.created_at, posts.id) < (sqlc.narg('cursor_time')::timestamptz, sqlc.narg('cursor_id')::uuid)
+  )
+ORDER BY posts.created_at DESC, posts.id DESC
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+-- name: SearchPostsForUser :many
+SELECT
+  posts.id,
+  posts.feed_id,
+  posts.title,
+  posts.url,
+  posts.author,
+  posts.published_at,
+  feeds.name AS feed_name,
+  post_is_filtered(feed_follows.user_id, posts.id)::boolean AS is_filtered,
+  ts_rank(posts.search_vector, websearch_to_tsquery('english', sqlc.arg('query')::text))::real AS rank,
+  ts_headline(
+    'english',
+    posts.title,
+    websearch_to_tsquery('english', sqlc.arg('query')::text),
+    'HighlightAll=true, StartSel=[[, StopSel=]]'
+  )::text AS title_headline,
+  ts_headline(
+    'english',
+    posts.description || ' ' || posts.content,
+    websearch_to_tsquery('english', sqlc.arg('query')::text),
+    'MaxFragments=2, MaxWords=25, MinWords=10, FragmentDelimiter=" ... ", StartSel=[[, StopSel=]]'
+  )::text AS snippet
+FROM posts
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+INNER JOIN feeds ON feeds.id = posts.feed_id
+WHERE feed_follows.user_id = sqlc.arg('user_id')
+  AND posts.search_vector @@ websearch_to_tsquery('english', sqlc.arg('query')::text)
+  AND (sqlc.arg('show_filtered')::boolean OR NOT post_is_filtered(feed_follows.user_id, posts.id))
+ORDER BY rank DESC, posts.published_at DESC NULLS LAST, posts.id DESC
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
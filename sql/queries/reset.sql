@@ -0,0 +1,45 @@
+-- name: CountResetAll :one
+SELECT
+  (SELECT COUNT(*) FROM users) AS users,
+  (SELECT COUNT(*) FROM feeds) AS feeds,
+  (SELECT COUNT(*) FROM feed_follows) AS feed_follows,
+  (SELECT COUNT(*) FROM posts) AS posts;
+
+-- name: CountResetPosts :one
+SELECT
+  COUNT(*) FILTER (WHERE bookmarks.post_id IS NULL) AS posts,
+  COUNT(DISTINCT posts.id) FILTER (WHERE bookmarks.post_id IS NOT NULL) AS starred_posts
+FROM posts
+LEFT JOIN bookmarks ON bookmarks.post_id = posts.id;
+
+-- name: CountResetUser :one
+SELECT
+  (SELECT COUNT(*) FROM feeds WHERE feeds.user_id = $1) AS feeds,
+  (
+    SELECT COUNT(*) FROM feed_follows
+    WHERE feed_follows.user_id = $1
+      OR feed_follows.feed_id IN (SELECT feeds.id FROM feeds WHERE feeds.user_id = $1)
+  ) AS feed_follows,
+  (
+    SELECT COUNT(*) FROM posts
+    WHERE posts.feed_id IN (SELECT feeds.id FROM feeds WHERE feeds.user_id = $1)
+  ) AS posts;
+
+-- name: CountResetFeedsCreatedBefore :one
+SELECT
+  (SELECT COUNT(*) FROM feeds WHERE feeds.created_at < $1) AS feeds,
+  (
+    SELECT COUNT(*) FROM feed_follows
+    WHERE feed_follows.feed_id IN (SELECT feeds.id FROM feeds WHERE feeds.created_at < $1)
+  ) AS feed_follows,
+  (
+    SELECT COUNT(*) FROM posts
+    WHERE posts.feed_id IN (SELECT feeds.id FROM feeds WHERE feeds.created_at < $1)
+  ) AS posts;
+
+-- name: DeleteUnstarredPosts :execrows
+DELETE FROM posts
+WHERE NOT EXISTS (SELECT 1 FROM bookmarks WHERE bookmarks.post_id = posts.id);
+
+-- name: DeleteFeedsCreatedBefore :execrows
+DELETE FROM feeds WHERE created_at < $1;
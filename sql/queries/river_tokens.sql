@@ -0,0 +1,18 @@
+-- name: SetRiverToken :one
+INSERT INTO river_tokens (user_id, token_hash, created_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id) DO UPDATE SET
+  token_hash = EXCLUDED.token_hash,
+  created_at = EXCLUDED.created_at
+RETURNING *;
+
+-- name: FindUserByRiverToken :one
+SELECT users.*
+FROM river_tokens
+INNER JOIN users ON users.id = river_tokens.user_id
+WHERE river_tokens.token_hash = $1
+LIMIT 1;
+
+-- name: DeleteRiverToken :execrows
+DELETE FROM river_tokens
+WHERE user_id = $1;
@@ -0,0 +1,20 @@
+-- name: CreateRule :one
+INSERT INTO rules (id, user_id, name, expression, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: FindRuleByName :one
+SELECT * FROM rules WHERE user_id = $1 AND name = $2 LIMIT 1;
+
+-- name: GetRulesForUser :many
+SELECT * FROM rules WHERE user_id = $1 ORDER BY name ASC;
+
+-- name: GetRulesForFeed :many
+SELECT rules.*
+FROM rules
+INNER JOIN feed_follows ON feed_follows.user_id = rules.user_id
+WHERE feed_follows.feed_id = $1
+ORDER BY rules.user_id, rules.name ASC;
+
+-- name: DeleteRule :execrows
+DELETE FROM rules WHERE user_id = $1 AND name = $2;
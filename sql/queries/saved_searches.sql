@@ -0,0 +1,46 @@
+-- name: CreateSavedSearch :one
+INSERT INTO saved_searches (id, user_id, name, query, notify, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: FindSavedSearchByName :one
+SELECT * FROM saved_searches WHERE user_id = $1 AND name = $2 LIMIT 1;
+
+-- name: GetSavedSearchesForUser :many
+SELECT
+  saved_searches.*,
+  (
+    SELECT COUNT(*)
+    FROM posts
+    INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+    LEFT JOIN post_reads ON post_reads.post_id = posts.id AND post_reads.user_id = feed_follows.user_id
+    WHERE feed_follows.user_id = saved_searches.user_id
+      AND post_reads.post_id IS NULL
+      AND posts.search_vector @@ websearch_to_tsquery('english', saved_searches.query)
+  ) AS unread_count
+FROM saved_searches
+WHERE saved_searches.user_id = $1
+ORDER BY saved_searches.name ASC;
+
+-- name: SetSavedSearchNotify :execrows
+UPDATE saved_searches SET notify = $1, updated_at = $2
+WHERE user_id = $3 AND name = $4;
+
+-- name: DeleteSavedSearch :execrows
+DELETE FROM saved_searches WHERE user_id = $1 AND name = $2;
+
+-- name: GetSavedSearchMatchesForPost :many
+SELECT
+  saved_searches.id,
+  saved_searches.name,
+  saved_searches.user_id,
+  users.name AS user_name
+FROM saved_searches
+INNER JOIN users ON users.id = saved_searches.user_id
+INNER JOIN feed_follows ON feed_follows.user_id = saved_searches.user_id
+INNER JOIN posts ON posts.feed_id = feed_follows.feed_id
+WHERE posts.id = $1
+  AND saved_searches.notify
+  AND NOT post_is_filtered(saved_searches.user_id, posts.id)
+  AND posts.search_vector @@ websearch_to_tsquery('english', saved_searches.query)
+ORDER BY users.name ASC, saved_searches.name ASC;
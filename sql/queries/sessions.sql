@@ -0,0 +1,24 @@
+-- name: CreateSession :one
+INSERT INTO sessions (id, user_id, token_hash, created_at, last_used_at, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: FindUserBySessionToken :one
+SELECT users.*
+FROM sessions
+INNER JOIN users ON users.id = sessions.user_id
+WHERE sessions.token_hash = sqlc.arg('token_hash')
+AND (sessions.expires_at IS NULL OR sessions.expires_at > sqlc.arg('now')::timestamptz)
+LIMIT 1;
+
+-- name: TouchSession :exec
+UPDATE sessions SET last_used_at = $1 WHERE token_hash = $2;
+
+-- name: DeleteSession :exec
+DELETE FROM sessions WHERE token_hash = $1;
+
+-- name: DeleteSessionsForUser :exec
+DELETE FROM sessions WHERE user_id = $1;
+
+-- name: DeleteExpiredSessions :exec
+DELETE FROM sessions WHERE expires_at <= $1;
@@ -1,6 +1,9 @@
+-- name: LockUsers :exec
+LOCK TABLE users IN SHARE ROW EXCLUSIVE MODE;
+
 -- name: CreateUser :one
-INSERT INTO users (id, name, created_at, updated_at)
-VALUES ($1, $2, $3, $4)
+INSERT INTO users (id, name, created_at, updated_at, password_hash, role)
+VALUES ($1, $2, $3, $4, $5, $6)
 RETURNING *;
 
 -- name: FindUserByeName :one
@@ -11,3 +14,34 @@ DELETE FROM users;
 
 -- name: GetUsers :many
 SELECT * FROM users;
+
+-- name: DeleteUser :execrows
+DELETE FROM users WHERE id = $1;
+
+-- name: RenameUser :exec
+UPDATE users SET name = $1, updated_at = $2 WHERE id = $3;
+
+-- name: GetUserStats :one
+SELECT
+  (SELECT COUNT(*) FROM feed_follows WHERE feed_follows.user_id = $1) AS follows,
+  (SELECT COUNT(*) FROM feeds WHERE feeds.user_id = $1) AS feeds_owned,
+  (
+    SELECT COUNT(*)
+    FROM posts
+    INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = $1
+    LEFT JOIN post_reads ON post_reads.post_id = posts.id AND post_reads.user_id = $1
+    WHERE post_reads.post_id IS NULL
+  ) AS unread_posts,
+  (SELECT COUNT(*) FROM bookmarks WHERE bookmarks.user_id = $1) AS starred_posts;
+
+-- name: SetUserPassword :exec
+UPDATE users SET password_hash = $1, updated_at = $2 WHERE id = $3;
+
+-- name: SetUserRole :exec
+UPDATE users SET role = $1, updated_at = $2 WHERE id = $3;
+
+-- name: CountAdmins :one
+SELECT COUNT(*) FROM users WHERE role = 'admin';
+
+-- name: CountUsers :one
+SELECT COUNT(*) FROM users;
@@ -0,0 +1,59 @@
+-- name: CreateWebhook :one
+INSERT INTO webhooks (id, user_id, name, url, format, secret, feed_id, tag, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING *;
+
+-- name: GetWebhooksForUser :many
+SELECT
+  webhooks.*,
+  feeds.name AS feed_name
+FROM webhooks
+LEFT JOIN feeds ON feeds.id = webhooks.feed_id
+WHERE webhooks.user_id = $1
+ORDER BY webhooks.name ASC;
+
+-- name: FindWebhookByName :one
+SELECT * FROM webhooks WHERE user_id = $1 AND name = $2;
+
+-- name: DeleteWebhook :execrows
+DELETE FROM webhooks WHERE user_id = $1 AND name = $2;
+
+-- name: GetWebhooksForPost :many
+SELECT
+  webhooks.*,
+  users.name AS user_name,
+  ARRAY(
+    SELECT post_tags.tag
+    FROM post_tags
+    WHERE post_tags.post_id = posts.id AND post_tags.user_id = webhooks.user_id
+    ORDER BY post_tags.tag ASC
+  )::text[] AS tags
+FROM webhooks
+INNER JOIN users ON users.id = webhooks.user_id
+INNER JOIN feed_follows ON feed_follows.user_id = webhooks.user_id
+INNER JOIN posts ON posts.feed_id = feed_follows.feed_id
+WHERE posts.id = $1
+  AND (webhooks.feed_id IS NULL OR webhooks.feed_id = posts.feed_id)
+  AND (webhooks.tag IS NULL OR EXISTS (
+    SELECT 1
+    FROM post_tags
+    WHERE post_tags.post_id = posts.id
+      AND post_tags.user_id = webhooks.user_id
+      AND post_tags.tag = webhooks.tag
+  ))
+  AND NOT post_is_filtered(webhooks.user_id, posts.id)
+ORDER BY users.name ASC, webhooks.name ASC;
+
+-- name: CreateWebhookDelivery :exec
+INSERT INTO webhook_deliveries (id, webhook_id, post_id, event, attempts, status_code, error, succeeded, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);
+
+-- name: GetWebhookDeliveries :many
+SELECT
+  webhook_deliveries.*,
+  posts.title AS post_title
+FROM webhook_deliveries
+LEFT JOIN posts ON posts.id = webhook_deliveries.post_id
+WHERE webhook_deliveries.webhook_id = $1
+ORDER BY webhook_deliveries.created_at DESC
+LIMIT $2;
@@ -0,0 +1,11 @@
+-- +goose up
+ALTER TABLE posts ADD COLUMN author TEXT NOT NULL DEFAULT '';
+CREATE INDEX posts_feed_id_published_idx ON posts (feed_id, (COALESCE(published_at, created_at)) DESC, id DESC);
+CREATE INDEX posts_feed_id_created_at_idx ON posts (feed_id, created_at DESC, id DESC);
+CREATE INDEX feeds_name_idx ON feeds (LOWER(name));
+
+-- +goose down
+DROP INDEX feeds_name_idx;
+DROP INDEX posts_feed_id_created_at_idx;
+DROP INDEX posts_feed_id_published_idx;
+ALTER TABLE posts DROP COLUMN author;
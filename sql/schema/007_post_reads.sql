@@ -0,0 +1,12 @@
+-- +goose up
+CREATE TABLE post_reads (
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+  read_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (user_id, post_id)
+);
+
+CREATE INDEX post_reads_post_id_idx ON post_reads (post_id);
+
+-- +goose down
+DROP TABLE post_reads;
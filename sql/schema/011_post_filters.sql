@@ -0,0 +1,48 @@
+-- +goose up
+ALTER TABLE posts ADD COLUMN categories TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE TABLE post_filters (
+  id UUID PRIMARY KEY NOT NULL DEFAULT gen_random_uuid(),
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  feed_id UUID REFERENCES feeds(id) ON DELETE CASCADE,
+  kind VARCHAR(20) NOT NULL CHECK (kind IN ('keyword', 'regex', 'author', 'category')),
+  pattern TEXT NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX post_filters_user_id_idx ON post_filters (user_id);
+
+-- A NULL feed_id makes the filter global for the user.
+-- +goose StatementBegin
+CREATE FUNCTION post_is_filtered(filter_user_id UUID, filtered_post_id UUID) RETURNS BOOLEAN
+LANGUAGE sql STABLE AS $$
+  SELECT EXISTS (
+    SELECT 1
+    FROM post_filters
+    INNER JOIN posts ON posts.id = filtered_post_id
+    WHERE post_filters.user_id = filter_user_id
+      AND (post_filters.feed_id IS NULL OR post_filters.feed_id = posts.feed_id)
+      AND CASE post_filters.kind
+        WHEN 'keyword' THEN
+          strpos(LOWER(posts.title), LOWER(post_filters.pattern)) > 0
+          OR strpos(LOWER(posts.description), LOWER(post_filters.pattern)) > 0
+        WHEN 'regex' THEN
+          posts.title ~* post_filters.pattern
+          OR posts.description ~* post_filters.pattern
+        WHEN 'author' THEN
+          strpos(LOWER(posts.author), LOWER(post_filters.pattern)) > 0
+        WHEN 'category' THEN
+          EXISTS (
+            SELECT 1 FROM unnest(posts.categories) AS category
+            WHERE LOWER(category) = LOWER(post_filters.pattern)
+          )
+        ELSE FALSE
+      END
+  );
+$$;
+-- +goose StatementEnd
+
+-- +goose down
+DROP FUNCTION post_is_filtered(UUID, UUID);
+DROP TABLE post_filters;
+ALTER TABLE posts DROP COLUMN categories;
@@ -0,0 +1,21 @@
+-- +goose up
+CREATE TABLE folders (
+  id UUID PRIMARY KEY NOT NULL DEFAULT gen_random_uuid(),
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  name VARCHAR(255) NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE(user_id, name)
+);
+
+CREATE TABLE folder_feeds (
+  folder_id UUID NOT NULL REFERENCES folders(id) ON DELETE CASCADE,
+  feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (folder_id, feed_id)
+);
+
+CREATE INDEX folder_feeds_feed_id_idx ON folder_feeds (feed_id);
+
+-- +goose down
+DROP TABLE folder_feeds;
+DROP TABLE folders;
@@ -0,0 +1,5 @@
+-- +goose up
+ALTER TABLE feeds ADD COLUMN site_url TEXT NOT NULL DEFAULT '';
+
+-- +goose down
+ALTER TABLE feeds DROP COLUMN site_url;
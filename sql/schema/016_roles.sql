@@ -0,0 +1,9 @@
+-- +goose up
+ALTER TABLE users ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'admin'));
+
+-- The oldest user becomes the administrator of an existing database.
+UPDATE users SET role = 'admin'
+WHERE id = (SELECT id FROM users ORDER BY created_at ASC LIMIT 1);
+
+-- +goose down
+ALTER TABLE users DROP COLUMN role;
@@ -0,0 +1,13 @@
+-- +goose up
+ALTER TABLE api_keys
+  ADD COLUMN label TEXT NOT NULL DEFAULT '',
+  ADD COLUMN scope VARCHAR(20) NOT NULL DEFAULT 'read-write' CHECK (scope IN ('read', 'read-write')),
+  ADD COLUMN last_used_at TIMESTAMP WITH TIME ZONE,
+  ADD COLUMN expires_at TIMESTAMP WITH TIME ZONE;
+
+-- +goose down
+ALTER TABLE api_keys
+  DROP COLUMN expires_at,
+  DROP COLUMN last_used_at,
+  DROP COLUMN scope,
+  DROP COLUMN label;
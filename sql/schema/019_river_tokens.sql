@@ -0,0 +1,9 @@
+-- +goose up
+CREATE TABLE river_tokens (
+  user_id UUID PRIMARY KEY NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  token_hash TEXT NOT NULL UNIQUE,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- +goose down
+DROP TABLE river_tokens;
@@ -0,0 +1,31 @@
+-- +goose up
+CREATE TABLE webhooks (
+  id UUID PRIMARY KEY NOT NULL DEFAULT gen_random_uuid(),
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  name VARCHAR(255) NOT NULL,
+  url TEXT NOT NULL,
+  format VARCHAR(20) NOT NULL DEFAULT 'generic' CHECK (format IN ('generic', 'slack', 'discord', 'mattermost')),
+  secret TEXT NOT NULL,
+  feed_id UUID REFERENCES feeds(id) ON DELETE CASCADE,
+  tag VARCHAR(255),
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE (user_id, name)
+);
+
+CREATE TABLE webhook_deliveries (
+  id UUID PRIMARY KEY NOT NULL DEFAULT gen_random_uuid(),
+  webhook_id UUID NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+  post_id UUID REFERENCES posts(id) ON DELETE SET NULL,
+  event VARCHAR(50) NOT NULL,
+  attempts INTEGER NOT NULL,
+  status_code INTEGER,
+  error TEXT NOT NULL DEFAULT '',
+  succeeded BOOLEAN NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX webhook_deliveries_webhook_id_idx ON webhook_deliveries (webhook_id, created_at DESC);
+
+-- +goose down
+DROP TABLE webhook_deliveries;
+DROP TABLE webhooks;
@@ -0,0 +1,32 @@
+-- +goose up
+CREATE TABLE digest_subscriptions (
+  user_id UUID PRIMARY KEY NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  email TEXT NOT NULL,
+  frequency VARCHAR(10) NOT NULL CHECK (frequency IN ('daily', 'weekly')),
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE digests (
+  id UUID PRIMARY KEY NOT NULL DEFAULT gen_random_uuid(),
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  email TEXT NOT NULL,
+  post_count INTEGER NOT NULL,
+  sent_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX digests_user_id_sent_at_idx ON digests (user_id, sent_at DESC);
+
+-- Posts already sent in a digest are never sent again
+CREATE TABLE digest_posts (
+  digest_id UUID NOT NULL REFERENCES digests(id) ON DELETE CASCADE,
+  post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+  PRIMARY KEY (digest_id, post_id)
+);
+
+CREATE INDEX digest_posts_post_id_idx ON digest_posts (post_id);
+
+-- +goose down
+DROP TABLE digest_posts;
+DROP TABLE digests;
+DROP TABLE digest_subscriptions;
@@ -0,0 +1,5 @@
+-- +goose up
+ALTER TABLE feed_follows ADD COLUMN notify BOOLEAN NOT NULL DEFAULT false;
+
+-- +goose down
+ALTER TABLE feed_follows DROP COLUMN notify;
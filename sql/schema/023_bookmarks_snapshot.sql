@@ -0,0 +1,37 @@
+-- +goose up
+-- Starred posts outlive their posts: a copy of the post is kept with the
+-- bookmark, so pruning feeds or posts does not delete them. Deleting a user
+-- still deletes their bookmarks.
+ALTER TABLE bookmarks
+  ADD COLUMN post_title TEXT NOT NULL DEFAULT '',
+  ADD COLUMN post_url TEXT NOT NULL DEFAULT '',
+  ADD COLUMN post_description TEXT NOT NULL DEFAULT '',
+  ADD COLUMN post_author TEXT NOT NULL DEFAULT '',
+  ADD COLUMN post_published_at TIMESTAMP WITH TIME ZONE,
+  ADD COLUMN feed_name TEXT NOT NULL DEFAULT '';
+
+UPDATE bookmarks SET
+  post_title = posts.title,
+  post_url = posts.url,
+  post_description = posts.description,
+  post_author = posts.author,
+  post_published_at = posts.published_at,
+  feed_name = feeds.name
+FROM posts
+INNER JOIN feeds ON feeds.id = posts.feed_id
+WHERE posts.id = bookmarks.post_id;
+
+ALTER TABLE bookmarks DROP CONSTRAINT bookmarks_post_id_fkey;
+
+-- +goose down
+DELETE FROM bookmarks WHERE NOT EXISTS (SELECT 1 FROM posts WHERE posts.id = bookmarks.post_id);
+
+ALTER TABLE bookmarks ADD CONSTRAINT bookmarks_post_id_fkey FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE;
+
+ALTER TABLE bookmarks
+  DROP COLUMN post_title,
+  DROP COLUMN post_url,
+  DROP COLUMN post_description,
+  DROP COLUMN post_author,
+  DROP COLUMN post_published_at,
+  DROP COLUMN feed_name;
@@ -0,0 +1,7 @@
+-- +goose up
+-- Tag filters are compared with the normalized tags rules set
+UPDATE webhooks SET tag = NULLIF(LOWER(TRIM(tag)), '') WHERE tag IS NOT NULL;
+
+-- +goose down
+-- The original spelling of the tags is not kept, nothing to undo
+SELECT 1;
@@ -0,0 +1,6 @@
+-- +goose up
+-- Web sessions expire with their cookie, CLI sessions last until logout
+ALTER TABLE sessions ADD COLUMN expires_at TIMESTAMP WITH TIME ZONE;
+
+-- +goose down
+ALTER TABLE sessions DROP COLUMN expires_at;
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/database"
+	"github.com/google/uuid"
+)
+
+const defaultStarredLimit = 20
+
+const starUsage = "Usage: gator star <post_id> [note | --clear-note]"
+
+// handleStar stars the post. A note replaces the note of an already starred
+// post, without a note the old one is kept unless --clear-note is given.
+func handleStar(s *state, cmd command, user database.User) error {
+	flags := newFlagSet(cmd.name)
+	clearNote := flags.Bool("clear-note", false, "remove the note of the starred post")
+
+	args, err := parseFlags(flags, cmd.args)
+	if err != nil {
+		return fmt.Errorf("%v\n%v", err, starUsage)
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("the star command requires a post ID. %v", starUsage)
+	}
+
+	post, err := findPostForUser(s, args[0], user.ID)
+	if err != nil {
+		return err
+	}
+
+	note := sql.NullString{}
+	if text := strings.TrimSpace(strings.Join(args[1:], " ")); text != "" {
+		if *clearNote {
+			return fmt.Errorf("a note cannot be given together with --clear-note. %v", starUsage)
+		}
+		note = sql.NullString{String: text, Valid: true}
+	} else if *clearNote {
+		note = sql.NullString{Valid: true}
+	}
+
+	bookmark, err := s.database.StarPost(context.Background(), database.StarPostParams{
+		UserID:    user.ID,
+		PostID:    post.ID,
+		Note:      note,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to star post: %v", err)
+	}
+
+	fmt.Printf("Starred: %v\n", post.Title)
+	if bookmark.Note != "" {
+		fmt.Printf("- Note: %v\n", bookmark.Note)
+	}
+
+	return nil
+}
+
+func handleUnstar(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return fmt.Errorf("the unstar command requires a post ID. Usage: gator unstar <post_id>")
+	}
+
+	// Bookmarks outlive follows, so the post is looked up by ID only
+	postID, err := uuid.Parse(cmd.args[0])
+	if err != nil {
+		return fmt.Errorf("invalid post ID %q: %v", cmd.args[0], err)
+	}
+
+	removedCount, err := s.database.UnstarPost(context.Background(), database.UnstarPostParams{
+		UserID: user.ID,
+		PostID: postID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to unstar post: %v", err)
+	}
+
+	if removedCount == 0 {
+		return fmt.Errorf("post %v is not starred", postID)
+	}
+
+	fmt.Printf("Unstarred: %v\n", postID)
+
+	return nil
+}
+
+func handleStarred(s *state, cmd command, user database.User) error {
+	limit := defaultStarredLimit
+	if len(cmd.args) > 0 {
+		parsedLimit, err := strconv.Atoi(cmd.args[0])
+		if err != nil || parsedLimit <= 0 {
+			return fmt.Errorf("invalid limit %q. Usage: gator starred [limit]", cmd.args[0])
+		}
+		limit = parsedLimit
+	}
+
+	bookmarks, err := s.database.GetBookmarksForUser(context.Background(), database.GetBookmarksForUserParams{
+		UserID: user.ID,
+		Limit:  int32(limit),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get starred posts: %v", err)
+	}
+
+	if len(bookmarks) == 0 {
+		fmt.Println("No starred posts found")
+		return nil
+	}
+
+	width := boxWidth()
+	for _, bookmark := range bookmarks {
+		renderPost(os.Stdout, postViewFromBookmark(bookmark), width)
+	}
+
+	return nil
+}
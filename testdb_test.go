@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/config"
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/database"
+	"github.com/google/uuid"
+)
+
+// Tests which need Postgres run against the database in GATOR_TEST_DB_URL and
+// are skipped without it. Every test gets its own schema with the migrations
+// applied, which is dropped when the test ends.
+const testDBEnv = "GATOR_TEST_DB_URL"
+
+func newTestState(t *testing.T) *state {
+	t.Helper()
+
+	dbURL := os.Getenv(testDBEnv)
+	if dbURL == "" {
+		t.Skipf("%v is not set", testDBEnv)
+	}
+
+	admin, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	defer admin.Close()
+
+	schema := "gator_test_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	if _, err := admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		t.Fatalf("failed to create test schema: %v", err)
+	}
+	t.Cleanup(func() {
+		admin, err := sql.Open("postgres", dbURL)
+		if err != nil {
+			t.Errorf("failed to open test database: %v", err)
+			return
+		}
+		defer admin.Close()
+
+		if _, err := admin.Exec("DROP SCHEMA " + schema + " CASCADE"); err != nil {
+			t.Errorf("failed to drop test schema: %v", err)
+		}
+	})
+
+	schemaURL, err := withSearchPath(dbURL, schema)
+	if err != nil {
+		t.Fatalf("invalid %v: %v", testDBEnv, err)
+	}
+
+	db, err := sql.Open("postgres", schemaURL)
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err := migrateUp(db); err != nil {
+		t.Fatal(err)
+	}
+
+	return &state{
+		config:   &config.Config{DBUrl: schemaURL},
+		database: database.New(db),
+		db:       db,
+	}
+}
+
+// withSearchPath sets the search_path of every connection, both URLs and
+// key=value connection strings are accepted.
+func withSearchPath(dbURL string, schema string) (string, error) {
+	if !strings.HasPrefix(dbURL, "postgres://") && !strings.HasPrefix(dbURL, "postgresql://") {
+		return fmt.Sprintf("%v search_path=%v", dbURL, schema), nil
+	}
+
+	parsed, err := url.Parse(dbURL)
+	if err != nil {
+		return "", err
+	}
+
+	query := parsed.Query()
+	query.Set("search_path", schema)
+	parsed.RawQuery = query.Encode()
+
+	return parsed.String(), nil
+}
+
+// migrateUp runs the "up" section of every migration in sql/schema, the same
+// statements goose runs.
+func migrateUp(db *sql.DB) error {
+	files, err := filepath.Glob(filepath.Join("sql", "schema", "*.sql"))
+	if err != nil {
+		return fmt.Errorf("failed to list migrations: %v", err)
+	}
+	slices.Sort(files)
+
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read migration: %v", err)
+		}
+
+		up, _, _ := strings.Cut(string(content), "-- +goose down")
+		if _, err := db.Exec(up); err != nil {
+			return fmt.Errorf("failed to run migration %v: %v", filepath.Base(file), err)
+		}
+	}
+
+	return nil
+}
+
+// testFixture is a user following a feed with a few posts, newest first.
+type testFixture struct {
+	user  database.User
+	feed  database.Feed
+	posts []database.Post
+}
+
+func createTestFixture(t *testing.T, s *state, userName string, postCount int) testFixture {
+	t.Helper()
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	user, err := registerUser(s, userName, "")
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	feed, err := s.database.CreateFeed(ctx, database.CreateFeedParams{
+		ID:     uuid.New(),
+		UserID: user.ID,
+		Url:    fmt.Sprintf("https://%v.example.com/feed.xml", userName),
+		Name:   userName + "'s blog",
+	})
+	if err != nil {
+		t.Fatalf("failed to create feed: %v", err)
+	}
+
+	_, err = s.database.CreateFeedFollow(ctx, database.CreateFeedFollowParams{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		FeedID:    feed.ID,
+		CreatedAt: now,
+	})
+	if err != nil {
+		t.Fatalf("failed to follow feed: %v", err)
+	}
+
+	fixture := testFixture{user: user, feed: feed}
+	for i := range postCount {
+		published := now.Add(-time.Duration(i) * time.Hour)
+		post, err := s.database.CreatePost(ctx, database.CreatePostParams{
+			ID:          uuid.New(),
+			FeedID:      feed.ID,
+			Title:       fmt.Sprintf("Post %v", i+1),
+			Url:         fmt.Sprintf("https://%v.example.com/posts/%v", userName, i+1),
+			Description: fmt.Sprintf("Description of post %v", i+1),
+			PublishedAt: sql.NullTime{Time: published, Valid: true},
+			CreatedAt:   now,
+			UpdatedAt:   now,
+			Categories:  []string{},
+		})
+		if err != nil {
+			t.Fatalf("failed to create post: %v", err)
+		}
+		fixture.posts = append(fixture.posts, post)
+	}
+
+	return fixture
+}
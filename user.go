@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/database"
+	"github.com/lib/pq"
+)
+
+const userUsage = `Usage:
+  gator user info
+  gator user rename <new_name>
+  gator user delete [--yes]
+  gator user promote <name>
+  gator user demote <name>`
+
+func handleUser(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return fmt.Errorf("the user command requires a sub command.\n%v", userUsage)
+	}
+
+	subCmd := command{
+		name: cmd.name + " " + cmd.args[0],
+		args: cmd.args[1:],
+	}
+
+	switch cmd.args[0] {
+	case "info":
+		return handleUserInfo(s, subCmd, user)
+	case "rename":
+		return handleUserRename(s, subCmd, user)
+	case "delete":
+		return handleUserDelete(s, subCmd, user)
+	case "promote":
+		return handleUserPromote(s, subCmd, user)
+	case "demote":
+		return handleUserDemote(s, subCmd, user)
+	}
+
+	return fmt.Errorf("unknown user sub command: %v\n%v", cmd.args[0], userUsage)
+}
+
+// formatAge describes a duration in the largest unit that fits, e.g.
+// "3 days" or "5 hours".
+func formatAge(age time.Duration) string {
+	units := []struct {
+		name     string
+		duration time.Duration
+	}{
+		{"year", 365 * 24 * time.Hour},
+		{"month", 30 * 24 * time.Hour},
+		{"day", 24 * time.Hour},
+		{"hour", time.Hour},
+		{"minute", time.Minute},
+	}
+
+	for _, unit := range units {
+		if n := int(age / unit.duration); n > 0 {
+			if n == 1 {
+				return fmt.Sprintf("1 %v", unit.name)
+			}
+			return fmt.Sprintf("%v %vs", n, unit.name)
+		}
+	}
+
+	return "less than a minute"
+}
+
+func handleUserInfo(s *state, cmd command, user database.User) error {
+	stats, err := s.database.GetUserStats(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get user stats: %v", err)
+	}
+
+	feedFollows, err := s.database.GetFeedFollowsForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get feed follows for user: %v", err)
+	}
+
+	fmt.Printf("User: %v\n", user.Name)
+	fmt.Printf("- Id: %v\n", user.ID)
+	fmt.Printf("- Role: %v\n", user.Role)
+	fmt.Printf("- Member since: %v (%v)\n", user.CreatedAt.Format("02 January 2006"), formatAge(time.Since(user.CreatedAt)))
+	fmt.Printf("- Feeds followed: %v\n", stats.Follows)
+	fmt.Printf("- Feeds added: %v\n", stats.FeedsOwned)
+	fmt.Printf("- Starred posts: %v\n", stats.StarredPosts)
+	fmt.Printf("- Unread posts: %v\n", stats.UnreadPosts)
+	for _, feedFollow := range feedFollows {
+		if feedFollow.UnreadCount > 0 {
+			fmt.Printf("  - %v: %v\n", feedFollow.FeedName, feedFollow.UnreadCount)
+		}
+	}
+
+	return nil
+}
+
+func handleUserRename(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return fmt.Errorf("the user rename command requires a new name.\n%v", userUsage)
+	}
+
+	newName := cmd.args[0]
+
+	err := s.database.RenameUser(context.Background(), database.RenameUserParams{
+		Name:      newName,
+		UpdatedAt: time.Now(),
+		ID:        user.ID,
+	})
+	if err != nil {
+		var pqErr *pq.Error
+
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return fmt.Errorf("%w: %v", ErrUserAlreadyExists, newName)
+		}
+
+		return fmt.Errorf("failed to rename user: %v", err)
+	}
+
+	if err := s.config.SetUser(newName); err != nil {
+		return fmt.Errorf("failed to update the current user: %v", err)
+	}
+
+	fmt.Printf("User renamed: %v -> %v\n", user.Name, newName)
+
+	return nil
+}
+
+func handleUserDelete(s *state, cmd command, user database.User) error {
+	flags := newFlagSet(cmd.name)
+	yes := flags.Bool("yes", false, "do not ask for confirmation")
+
+	if _, err := parseFlags(flags, cmd.args); err != nil {
+		return fmt.Errorf("%v\n%v", err, userUsage)
+	}
+
+	if err := checkLastAdmin(s, user); err != nil {
+		return err
+	}
+
+	counts, err := s.database.CountResetUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to count rows: %v", err)
+	}
+
+	fmt.Printf("Deleting the user %v also deletes:\n", user.Name)
+	fmt.Printf("- Feeds added by the user (also for their other followers): %v\n", counts.Feeds)
+	fmt.Printf("- Feed follows: %v\n", counts.FeedFollows)
+	fmt.Printf("- Posts: %v\n", counts.Posts)
+
+	if !*yes {
+		confirmed, err := confirm("This cannot be undone.")
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			fmt.Println("User deletion cancelled")
+			return nil
+		}
+	}
+
+	if err := deleteUser(s, user); err != nil {
+		return err
+	}
+
+	fmt.Printf("User deleted: %v\n", user.Name)
+
+	return nil
+}
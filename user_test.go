@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/auth"
+)
+
+func TestRegisterUserConcurrentFirstAdmin(t *testing.T) {
+	s := newTestState(t)
+
+	const userCount = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, userCount)
+	for i := range userCount {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := registerUser(s, fmt.Sprintf("user%v", i), ""); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("registerUser failed: %v", err)
+	}
+
+	admins, err := s.database.CountAdmins(context.Background())
+	if err != nil {
+		t.Fatalf("failed to count admins: %v", err)
+	}
+	if admins != 1 {
+		t.Errorf("%v users registering at once made %v administrators, want 1", userCount, admins)
+	}
+}
+
+func TestRegisterUserExists(t *testing.T) {
+	s := newTestState(t)
+
+	if _, err := registerUser(s, "alice", ""); err != nil {
+		t.Fatalf("registerUser failed: %v", err)
+	}
+
+	user, err := registerUser(s, "alice", "")
+	if err != ErrUserAlreadyExists {
+		t.Fatalf("registering alice twice = %+v, %v, want ErrUserAlreadyExists", user, err)
+	}
+}
+
+func TestRegisterUserWithPassword(t *testing.T) {
+	s := newTestState(t)
+
+	hash, err := auth.HashPassword("correct horse")
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+
+	if _, err := registerUser(s, "alice", hash); err != nil {
+		t.Fatalf("registerUser failed: %v", err)
+	}
+
+	user, err := s.database.FindUserByeName(context.Background(), "alice")
+	if err != nil {
+		t.Fatalf("failed to find user: %v", err)
+	}
+	if user.PasswordHash != hash {
+		t.Errorf("password hash = %q, want %q", user.PasswordHash, hash)
+	}
+}
@@ -0,0 +1,532 @@
+package main
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"embed"
+	"errors"
+	"html"
+	"html/template"
+	"io/fs"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/auth"
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	sessionCookieName = "gator_session"
+	sessionCookieAge  = 30 * 24 * time.Hour
+
+	webPostLimit     = 25
+	webExcerptLength = 300
+)
+
+//go:embed web/templates/*.html web/static/*
+var webFiles embed.FS
+
+// webServer serves the reading UI of "gator serve". Pages are rendered on the
+// server, forms post back and redirect, so no JavaScript is needed.
+type webServer struct {
+	state     *state
+	templates map[string]*template.Template
+	logins    *loginLimiter
+}
+
+type webHandler func(w http.ResponseWriter, r *http.Request, session webSession)
+
+// webSession is the logged in user of a request. The CSRF token is derived
+// from the session token and has to be sent back with every form.
+type webSession struct {
+	User      database.User
+	CSRFToken string
+}
+
+// webPage is passed to every template, Data holds the page specific values.
+type webPage struct {
+	Title   string
+	Session *webSession
+	Error   string
+	Data    any
+}
+
+// webFilters are the narrowing options of the river page, a subset of the
+// browse options.
+type webFilters struct {
+	Feed   string
+	Folder string
+	Tag    string
+	Query  string
+	Unread bool
+}
+
+type webPostsData struct {
+	Posts      []postView
+	Filters    webFilters
+	NextURL    string
+	CurrentURL string
+}
+
+type webFeedsData struct {
+	Follows []database.GetFeedFollowsForUserRow
+}
+
+var webTemplateFuncs = template.FuncMap{
+	"excerpt": excerpt,
+	"date": func(t sql.NullTime) string {
+		if !t.Valid {
+			return ""
+		}
+		return t.Time.Format("02 January 2006 15:04")
+	},
+	"postsURL": func(key string, value string) string {
+		return "/posts?" + url.Values{key: {value}}.Encode()
+	},
+}
+
+func newWebServer(s *state) *webServer {
+	web := &webServer{
+		state:     s,
+		templates: make(map[string]*template.Template),
+		logins:    newLoginLimiter(),
+	}
+
+	for _, page := range []string{"login.html", "posts.html", "feeds.html", "error.html"} {
+		web.templates[page] = template.Must(
+			template.New(page).Funcs(webTemplateFuncs).ParseFS(webFiles, "web/templates/layout.html", "web/templates/"+page),
+		)
+	}
+
+	return web
+}
+
+func (web *webServer) register(mux *http.ServeMux) {
+	static, err := fs.Sub(webFiles, "web/static")
+	if err != nil {
+		panic(err)
+	}
+
+	mux.Handle("GET /static/", http.StripPrefix("/static/", http.FileServer(http.FS(static))))
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/posts", http.StatusSeeOther)
+	})
+	mux.HandleFunc("GET /login", web.handleLoginPage)
+	mux.HandleFunc("POST /login", web.handleLogin)
+	mux.Handle("POST /logout", web.authenticated(web.handleLogout))
+	mux.Handle("GET /posts", web.authenticated(web.handlePosts))
+	mux.Handle("GET /feeds", web.authenticated(web.handleFeeds))
+	mux.Handle("POST /posts/{postID}/read", web.authenticated(web.handleMarkRead))
+	mux.Handle("POST /posts/{postID}/unread", web.authenticated(web.handleMarkUnread))
+	mux.Handle("POST /posts/{postID}/star", web.authenticated(web.handleStar))
+	mux.Handle("POST /posts/{postID}/unstar", web.authenticated(web.handleUnstar))
+}
+
+func (web *webServer) render(w http.ResponseWriter, code int, name string, page webPage) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(code)
+
+	if err := web.templates[name].ExecuteTemplate(w, "layout", page); err != nil {
+		slog.Error("failed to render template", "template", name, "error", err)
+	}
+}
+
+func (web *webServer) renderError(w http.ResponseWriter, code int, session *webSession, message string) {
+	web.render(w, code, "error.html", webPage{
+		Title:   http.StatusText(code),
+		Session: session,
+		Error:   message,
+	})
+}
+
+// excerpt turns the HTML description of a post into a short plain text.
+func excerpt(description string) string {
+	var text strings.Builder
+	inTag := false
+	for _, r := range description {
+		switch {
+		case r == '<':
+			inTag = true
+		case r == '>' && inTag:
+			inTag = false
+			text.WriteRune(' ')
+		case !inTag:
+			text.WriteRune(r)
+		}
+	}
+
+	plain := sanitizeText(html.UnescapeString(text.String()))
+	if utf8.RuneCountInString(plain) <= webExcerptLength {
+		return plain
+	}
+
+	return string([]rune(plain)[:webExcerptLength]) + "…"
+}
+
+func csrfToken(sessionToken string) string {
+	return auth.HashSessionToken("csrf:" + sessionToken)
+}
+
+func setSessionCookie(w http.ResponseWriter, r *http.Request, token string, maxAge time.Duration) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    token,
+		Path:     "/",
+		MaxAge:   int(maxAge.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// authenticated resolves the user from the session cookie, the web
+// counterpart of middlewareLoggedIn. Visitors without a session are sent to
+// the login page and form posts without a valid CSRF token are rejected.
+func (web *webServer) authenticated(handler webHandler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie(sessionCookieName)
+		if err != nil || cookie.Value == "" {
+			http.Redirect(w, r, "/login", http.StatusSeeOther)
+			return
+		}
+
+		tokenHash := auth.HashSessionToken(cookie.Value)
+
+		user, err := web.state.database.FindUserBySessionToken(r.Context(), database.FindUserBySessionTokenParams{
+			TokenHash: tokenHash,
+			Now:       time.Now(),
+		})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				setSessionCookie(w, r, "", -1)
+				http.Redirect(w, r, "/login", http.StatusSeeOther)
+				return
+			}
+
+			slog.Error("failed to find session", "error", err)
+			web.renderError(w, http.StatusInternalServerError, nil, "failed to find session")
+			return
+		}
+
+		err = web.state.database.TouchSession(r.Context(), database.TouchSessionParams{
+			LastUsedAt: time.Now(),
+			TokenHash:  tokenHash,
+		})
+		if err != nil {
+			slog.Error("failed to update session", "error", err)
+		}
+
+		session := webSession{
+			User:      user,
+			CSRFToken: csrfToken(cookie.Value),
+		}
+
+		if r.Method == http.MethodPost {
+			formToken := r.PostFormValue("csrf_token")
+			if subtle.ConstantTimeCompare([]byte(formToken), []byte(session.CSRFToken)) != 1 {
+				web.renderError(w, http.StatusForbidden, &session, "invalid form token, reload the page and try again")
+				return
+			}
+		}
+
+		handler(w, r, session)
+	})
+}
+
+func (web *webServer) handleLoginPage(w http.ResponseWriter, r *http.Request) {
+	web.render(w, http.StatusOK, "login.html", webPage{Title: "Log in"})
+}
+
+// handleLogin starts a session like "gator login". The web UI may be reachable
+// from other machines, so only users with a password can log in, failed
+// attempts are limited and the session expires with its cookie.
+func (web *webServer) handleLogin(w http.ResponseWriter, r *http.Request) {
+	userName := r.PostFormValue("username")
+	ipKey, userKey := loginKeys(r, userName)
+
+	if wait := web.logins.retryAfter(ipKey, userKey); wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		web.render(w, http.StatusTooManyRequests, "login.html", webPage{Title: "Log in", Error: "too many failed logins, try again later"})
+		return
+	}
+
+	loginFailed := func(message string) {
+		web.logins.failed(ipKey, userKey)
+		web.render(w, http.StatusUnauthorized, "login.html", webPage{Title: "Log in", Error: message})
+	}
+
+	user, err := web.state.database.FindUserByeName(r.Context(), userName)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			loginFailed("invalid user name or password")
+			return
+		}
+
+		slog.Error("failed to find user", "error", err)
+		web.render(w, http.StatusInternalServerError, "login.html", webPage{Title: "Log in", Error: "failed to log in"})
+		return
+	}
+
+	if user.PasswordHash == "" {
+		loginFailed("set a password with \"gator passwd\" to use the web interface")
+		return
+	}
+
+	ok, err := auth.CheckPassword(r.PostFormValue("password"), user.PasswordHash)
+	if err != nil || !ok {
+		loginFailed("invalid user name or password")
+		return
+	}
+
+	token, err := auth.NewSessionToken()
+	if err != nil {
+		slog.Error("failed to generate session token", "error", err)
+		web.render(w, http.StatusInternalServerError, "login.html", webPage{Title: "Log in", Error: "failed to log in"})
+		return
+	}
+
+	web.logins.succeeded(userKey)
+
+	now := time.Now()
+	_, err = web.state.database.CreateSession(r.Context(), database.CreateSessionParams{
+		ID:         uuid.New(),
+		UserID:     user.ID,
+		TokenHash:  auth.HashSessionToken(token),
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  sql.NullTime{Time: now.Add(sessionCookieAge), Valid: true},
+	})
+	if err != nil {
+		slog.Error("failed to create session", "error", err)
+		web.render(w, http.StatusInternalServerError, "login.html", webPage{Title: "Log in", Error: "failed to log in"})
+		return
+	}
+
+	// Expired sessions are already rejected, this only keeps the table small
+	if err := web.state.database.DeleteExpiredSessions(r.Context(), sql.NullTime{Time: now, Valid: true}); err != nil {
+		slog.Error("failed to delete expired sessions", "error", err)
+	}
+
+	setSessionCookie(w, r, token, sessionCookieAge)
+	http.Redirect(w, r, "/posts", http.StatusSeeOther)
+}
+
+func (web *webServer) handleLogout(w http.ResponseWriter, r *http.Request, session webSession) {
+	if cookie, err := r.Cookie(sessionCookieName); err == nil {
+		if err := web.state.database.DeleteSession(r.Context(), auth.HashSessionToken(cookie.Value)); err != nil {
+			slog.Error("failed to revoke session", "error", err)
+		}
+	}
+
+	setSessionCookie(w, r, "", -1)
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
+
+func (web *webServer) handlePosts(w http.ResponseWriter, r *http.Request, session webSession) {
+	values := r.URL.Query()
+
+	filters := webFilters{
+		Feed:   values.Get("feed"),
+		Folder: values.Get("folder"),
+		Tag:    values.Get("tag"),
+		Query:  strings.TrimSpace(values.Get("q")),
+		Unread: values.Get("unread") == "true",
+	}
+
+	opts := browseOptions{
+		limit:  webPostLimit,
+		sortBy: sortByPublished,
+		cursor: values.Get("cursor"),
+		feed:   filters.Feed,
+		folder: filters.Folder,
+		tag:    filters.Tag,
+		query:  filters.Query,
+		unread: filters.Unread,
+	}
+
+	params, err := buildPostsQuery(session.User.ID, opts, time.Now())
+	if err != nil {
+		web.renderError(w, http.StatusBadRequest, &session, err.Error())
+		return
+	}
+
+	userPosts, err := getPostsForUser(r.Context(), web.state.database, opts.sortBy, params)
+	if err != nil {
+		slog.Error("failed to get posts", "error", err)
+		web.renderError(w, http.StatusInternalServerError, &session, "failed to get posts")
+		return
+	}
+
+	data := webPostsData{
+		Filters:    filters,
+		CurrentURL: r.URL.RequestURI(),
+	}
+	for _, post := range userPosts {
+		data.Posts = append(data.Posts, postViewFromUserPost(post))
+	}
+
+	if len(userPosts) == opts.limit {
+		lastPost := userPosts[len(userPosts)-1]
+		next := r.URL.Query()
+		next.Set("cursor", encodeCursor(opts.sortBy, lastPost.SortKey, lastPost.ID))
+		data.NextURL = "/posts?" + next.Encode()
+	}
+
+	title := "River"
+	if filters.Query != "" {
+		title = "Search: " + filters.Query
+	}
+
+	web.render(w, http.StatusOK, "posts.html", webPage{
+		Title:   title,
+		Session: &session,
+		Data:    data,
+	})
+}
+
+func (web *webServer) handleFeeds(w http.ResponseWriter, r *http.Request, session webSession) {
+	feedFollows, err := web.state.database.GetFeedFollowsForUser(r.Context(), session.User.ID)
+	if err != nil {
+		slog.Error("failed to get feed follows", "error", err)
+		web.renderError(w, http.StatusInternalServerError, &session, "failed to get feeds")
+		return
+	}
+
+	web.render(w, http.StatusOK, "feeds.html", webPage{
+		Title:   "Feeds",
+		Session: &session,
+		Data:    webFeedsData{Follows: feedFollows},
+	})
+}
+
+// redirectBack returns to the page the form was posted from. Only local
+// paths are accepted to not turn the form into an open redirect.
+func redirectBack(w http.ResponseWriter, r *http.Request) {
+	target := r.PostFormValue("return_to")
+	if !isLocalPath(target) {
+		target = "/posts"
+	}
+
+	http.Redirect(w, r, target, http.StatusSeeOther)
+}
+
+// isLocalPath reports whether the target stays on this site. Browsers treat
+// a backslash like a slash, so "/\evil.example" is protocol relative as well.
+// Control characters are rejected by url.Parse, browsers would drop them.
+func isLocalPath(target string) bool {
+	if !strings.HasPrefix(target, "/") || strings.HasPrefix(target, "//") || strings.HasPrefix(target, "/\\") {
+		return false
+	}
+
+	parsed, err := url.Parse(target)
+	if err != nil {
+		return false
+	}
+
+	return parsed.Scheme == "" && parsed.Host == ""
+}
+
+// findWebPost looks up the post of the {postID} path parameter among the
+// posts of the feeds the user follows. It responds with an error and returns
+// false if the post cannot be used.
+func (web *webServer) findWebPost(w http.ResponseWriter, r *http.Request, session webSession) (uuid.UUID, bool) {
+	post, err := findPostForUser(web.state, r.PathValue("postID"), session.User.ID)
+	if err != nil {
+		if errors.Is(err, ErrPostNotFound) {
+			web.renderError(w, http.StatusNotFound, &session, err.Error())
+			return uuid.Nil, false
+		}
+
+		web.renderError(w, http.StatusBadRequest, &session, err.Error())
+		return uuid.Nil, false
+	}
+
+	return post.ID, true
+}
+
+func (web *webServer) handleMarkRead(w http.ResponseWriter, r *http.Request, session webSession) {
+	postID, ok := web.findWebPost(w, r, session)
+	if !ok {
+		return
+	}
+
+	err := web.state.database.MarkPostRead(r.Context(), database.MarkPostReadParams{
+		UserID: session.User.ID,
+		PostID: postID,
+		ReadAt: time.Now(),
+	})
+	if err != nil {
+		slog.Error("failed to mark post as read", "error", err)
+		web.renderError(w, http.StatusInternalServerError, &session, "failed to mark post as read")
+		return
+	}
+
+	redirectBack(w, r)
+}
+
+func (web *webServer) handleMarkUnread(w http.ResponseWriter, r *http.Request, session webSession) {
+	postID, ok := web.findWebPost(w, r, session)
+	if !ok {
+		return
+	}
+
+	_, err := web.state.database.MarkPostUnread(r.Context(), database.MarkPostUnreadParams{
+		UserID: session.User.ID,
+		PostID: postID,
+	})
+	if err != nil {
+		slog.Error("failed to mark post as unread", "error", err)
+		web.renderError(w, http.StatusInternalServerError, &session, "failed to mark post as unread")
+		return
+	}
+
+	redirectBack(w, r)
+}
+
+func (web *webServer) handleStar(w http.ResponseWriter, r *http.Request, session webSession) {
+	postID, ok := web.findWebPost(w, r, session)
+	if !ok {
+		return
+	}
+
+	_, err := web.state.database.StarPost(r.Context(), database.StarPostParams{
+		UserID:    session.User.ID,
+		PostID:    postID,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	})
+	if err != nil {
+		slog.Error("failed to star post", "error", err)
+		web.renderError(w, http.StatusInternalServerError, &session, "failed to star post")
+		return
+	}
+
+	redirectBack(w, r)
+}
+
+func (web *webServer) handleUnstar(w http.ResponseWriter, r *http.Request, session webSession) {
+	postID, err := uuid.Parse(r.PathValue("postID"))
+	if err != nil {
+		web.renderError(w, http.StatusBadRequest, &session, "invalid post ID")
+		return
+	}
+
+	_, err = web.state.database.UnstarPost(r.Context(), database.UnstarPostParams{
+		UserID: session.User.ID,
+		PostID: postID,
+	})
+	if err != nil {
+		slog.Error("failed to unstar post", "error", err)
+		web.renderError(w, http.StatusInternalServerError, &session, "failed to unstar post")
+		return
+	}
+
+	redirectBack(w, r)
+}
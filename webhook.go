@@ -0,0 +1,512 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/auth"
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/database"
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/rules"
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/webhook"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	defaultWebhookLogLimit = 20
+
+	// Deliveries queued while aggregating, beyond this new ones are dropped
+	webhookQueueSize = 100
+)
+
+var ErrWebhookNotFound = errors.New("webhook not found")
+var ErrWebhookExists = errors.New("webhook already exists")
+var ErrWebhookQueueFull = errors.New("the webhook queue is full, the delivery was dropped")
+
+const webhookUsage = `Usage:
+  gator webhook add <name> <url> [--format generic|slack|discord|mattermost] [--feed feed_url] [--tag tag] [--secret secret]
+  gator webhook list
+  gator webhook remove <name>
+  gator webhook test <name>
+  gator webhook log <name> [--limit 20]`
+
+// Webhooks receive a JSON payload for every new post of the feeds followed by
+// their owner, optionally narrowed to a single feed or a tag set by rules.
+func handleWebhook(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return fmt.Errorf("the webhook command requires a sub command.\n%v", webhookUsage)
+	}
+
+	subCmd := command{
+		name: cmd.name + " " + cmd.args[0],
+		args: cmd.args[1:],
+	}
+
+	switch cmd.args[0] {
+	case "add":
+		return handleWebhookAdd(s, subCmd, user)
+	case "list":
+		return handleWebhookList(s, subCmd, user)
+	case "remove":
+		return handleWebhookRemove(s, subCmd, user)
+	case "test":
+		return handleWebhookTest(s, subCmd, user)
+	case "log":
+		return handleWebhookLog(s, subCmd, user)
+	}
+
+	return fmt.Errorf("unknown webhook sub command: %v\n%v", cmd.args[0], webhookUsage)
+}
+
+func findWebhook(s *state, userID uuid.UUID, name string) (database.Webhook, error) {
+	hook, err := s.database.FindWebhookByName(context.Background(), database.FindWebhookByNameParams{
+		UserID: userID,
+		Name:   name,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return database.Webhook{}, fmt.Errorf("%w: %v", ErrWebhookNotFound, name)
+		}
+
+		return database.Webhook{}, fmt.Errorf("failed to find webhook: %v", err)
+	}
+
+	return hook, nil
+}
+
+func handleWebhookAdd(s *state, cmd command, user database.User) error {
+	flags := newFlagSet(cmd.name)
+	format := flags.String("format", webhook.FormatGeneric, "payload format: "+strings.Join(webhook.Formats, ", "))
+	feedArg := flags.String("feed", "", "only send posts of the feed with this URL")
+	tag := flags.String("tag", "", "only send posts tagged with this tag by your rules")
+	secret := flags.String("secret", "", "secret signing the deliveries (generated when empty)")
+
+	args, err := parseFlags(flags, cmd.args)
+	if err != nil {
+		return fmt.Errorf("%v\n%v", err, webhookUsage)
+	}
+	if len(args) != 2 {
+		return fmt.Errorf("the webhook add command requires a name and a URL.\n%v", webhookUsage)
+	}
+
+	name := args[0]
+	hookURL := args[1]
+
+	parsedURL, err := url.Parse(hookURL)
+	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") || parsedURL.Host == "" {
+		return fmt.Errorf("invalid webhook URL %q, it must be an http or https URL", hookURL)
+	}
+
+	if !slices.Contains(webhook.Formats, *format) {
+		return fmt.Errorf("invalid webhook format %q, valid formats are: %v", *format, strings.Join(webhook.Formats, ", "))
+	}
+
+	feedID := uuid.NullUUID{}
+	if *feedArg != "" {
+		feed, err := s.database.FindFeedByUrl(context.Background(), *feedArg)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("feed not found: %v", *feedArg)
+			}
+
+			return fmt.Errorf("failed to get feed by URL: %v", err)
+		}
+
+		feedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+	}
+
+	// Rules store tags normalized, the filter has to match them exactly
+	tagFilter := sql.NullString{}
+	if normalized := rules.NormalizeTag(*tag); normalized != "" {
+		tagFilter = sql.NullString{String: normalized, Valid: true}
+	}
+
+	if *secret == "" {
+		// Secrets are random like session tokens
+		*secret, err = auth.NewSessionToken()
+		if err != nil {
+			return fmt.Errorf("failed to generate webhook secret: %v", err)
+		}
+	}
+
+	hook, err := s.database.CreateWebhook(context.Background(), database.CreateWebhookParams{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		Name:      name,
+		Url:       hookURL,
+		Format:    *format,
+		Secret:    *secret,
+		FeedID:    feedID,
+		Tag:       tagFilter,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		var pqErr *pq.Error
+
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return fmt.Errorf("%w: %v", ErrWebhookExists, name)
+		}
+
+		return fmt.Errorf("failed to create webhook: %v", err)
+	}
+
+	fmt.Printf("Webhook successfully added\n")
+	printWebhook(hook, *feedArg)
+	fmt.Printf("- Secret: %v\n", hook.Secret)
+	fmt.Printf("Deliveries are signed in the %v header, see the README to verify them\n", webhook.HeaderSignature)
+	fmt.Printf("Send a test payload with: gator webhook test %v\n", hook.Name)
+
+	return nil
+}
+
+func printWebhook(hook database.Webhook, feedName string) {
+	applies := "all followed feeds"
+	if feedName != "" {
+		applies = feedName
+	}
+	if hook.Tag.Valid {
+		applies += fmt.Sprintf(", posts tagged %q", hook.Tag.String)
+	}
+
+	fmt.Printf("- Name:    %v\n", hook.Name)
+	fmt.Printf("- URL:     %v\n", hook.Url)
+	fmt.Printf("- Format:  %v\n", hook.Format)
+	fmt.Printf("- Applies: %v\n", applies)
+}
+
+func handleWebhookList(s *state, cmd command, user database.User) error {
+	hooks, err := s.database.GetWebhooksForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get webhooks: %v", err)
+	}
+
+	if len(hooks) == 0 {
+		fmt.Println("No webhooks found. Use: gator webhook add <name> <url>")
+		return nil
+	}
+
+	fmt.Printf("Webhooks of %v\n", user.Name)
+	fmt.Printf("--------------------------------\n")
+	for _, hook := range hooks {
+		printWebhook(database.Webhook{
+			Name:   hook.Name,
+			Url:    hook.Url,
+			Format: hook.Format,
+			Tag:    hook.Tag,
+		}, hook.FeedName.String)
+		fmt.Printf("--------------------------------\n")
+	}
+
+	return nil
+}
+
+func handleWebhookRemove(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return fmt.Errorf("the webhook remove command requires a name.\n%v", webhookUsage)
+	}
+
+	name := cmd.args[0]
+
+	removedCount, err := s.database.DeleteWebhook(context.Background(), database.DeleteWebhookParams{
+		UserID: user.ID,
+		Name:   name,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove webhook: %v", err)
+	}
+
+	if removedCount == 0 {
+		return fmt.Errorf("%w: %v", ErrWebhookNotFound, name)
+	}
+
+	fmt.Printf("Webhook removed: %v\n", name)
+
+	return nil
+}
+
+// handleWebhookTest sends a sample post to the webhook right away, retrying
+// like real deliveries, and records the delivery in the log.
+func handleWebhookTest(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return fmt.Errorf("the webhook test command requires a name.\n%v", webhookUsage)
+	}
+
+	hook, err := findWebhook(s, user.ID, cmd.args[0])
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	event := webhook.Event{
+		Event:   webhook.EventTest,
+		Webhook: hook.Name,
+		User:    user.Name,
+		SentAt:  now,
+		Post: webhook.Post{
+			ID:          uuid.Nil.String(),
+			FeedID:      uuid.Nil.String(),
+			FeedName:    "gator",
+			Title:       "Test post from gator",
+			Summary:     "If you can read this, the webhook works.",
+			Tags:        []string{},
+			PublishedAt: &now,
+		},
+	}
+
+	fmt.Printf("Sending a test payload to %v\n", hook.Url)
+
+	result, err := deliverWebhook(s, hook, event, uuid.NullUUID{})
+	if err != nil {
+		return err
+	}
+	if result.Err != nil {
+		return fmt.Errorf("webhook test failed after %v attempt(s): %v", result.Attempts, result.Err)
+	}
+
+	fmt.Printf("Webhook test succeeded (status %v)\n", result.StatusCode)
+
+	return nil
+}
+
+func handleWebhookLog(s *state, cmd command, user database.User) error {
+	flags := newFlagSet(cmd.name)
+	limit := flags.Int("limit", defaultWebhookLogLimit, "number of deliveries to show")
+
+	args, err := parseFlags(flags, cmd.args)
+	if err != nil {
+		return fmt.Errorf("%v\n%v", err, webhookUsage)
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("the webhook log command requires a name.\n%v", webhookUsage)
+	}
+	if *limit <= 0 {
+		return fmt.Errorf("the limit must be a positive number")
+	}
+
+	hook, err := findWebhook(s, user.ID, args[0])
+	if err != nil {
+		return err
+	}
+
+	deliveries, err := s.database.GetWebhookDeliveries(context.Background(), database.GetWebhookDeliveriesParams{
+		WebhookID: hook.ID,
+		Limit:     int32(*limit),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get webhook deliveries: %v", err)
+	}
+
+	if len(deliveries) == 0 {
+		fmt.Printf("No deliveries yet. Use: gator webhook test %v\n", hook.Name)
+		return nil
+	}
+
+	fmt.Printf("Deliveries of %v, newest first\n", hook.Name)
+	fmt.Printf("--------------------------------\n")
+	for _, delivery := range deliveries {
+		status := "no response"
+		if delivery.StatusCode.Valid {
+			status = fmt.Sprintf("%v", delivery.StatusCode.Int32)
+		}
+		outcome := "delivered"
+		if !delivery.Succeeded {
+			outcome = "failed"
+		}
+
+		fmt.Printf("- Time:     %v\n", delivery.CreatedAt.Format("02 January 2006 15:04:05"))
+		fmt.Printf("- Event:    %v\n", delivery.Event)
+		if delivery.PostTitle.Valid {
+			fmt.Printf("- Post:     %v\n", delivery.PostTitle.String)
+		}
+		fmt.Printf("- Outcome:  %v after %v attempt(s)\n", outcome, delivery.Attempts)
+		fmt.Printf("- Status:   %v\n", status)
+		if delivery.Error != "" {
+			fmt.Printf("- Error:    %v\n", delivery.Error)
+		}
+		fmt.Printf("--------------------------------\n")
+	}
+
+	return nil
+}
+
+// deliverWebhook sends the event to the webhook and records the outcome in the
+// delivery log. The returned error is only set when the delivery could not be
+// attempted or logged, failed deliveries are reported in the result.
+func deliverWebhook(s *state, hook database.Webhook, event webhook.Event, postID uuid.NullUUID) (webhook.Result, error) {
+	body, err := webhook.Body(hook.Format, event)
+	if err != nil {
+		return webhook.Result{}, fmt.Errorf("failed to encode webhook payload: %v", err)
+	}
+
+	result := webhook.NewSender().Send(context.Background(), hook.Url, hook.Secret, event.Event, body)
+
+	if err := recordWebhookDelivery(s, hook, postID, event.Event, result); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// recordWebhookDelivery adds the delivery to the log shown by "gator webhook
+// log".
+func recordWebhookDelivery(s *state, hook database.Webhook, postID uuid.NullUUID, event string, result webhook.Result) error {
+	statusCode := sql.NullInt32{}
+	if result.StatusCode != 0 {
+		statusCode = sql.NullInt32{Int32: int32(result.StatusCode), Valid: true}
+	}
+	errorMessage := ""
+	if result.Err != nil {
+		errorMessage = result.Err.Error()
+	}
+
+	err := s.database.CreateWebhookDelivery(context.Background(), database.CreateWebhookDeliveryParams{
+		ID:         uuid.New(),
+		WebhookID:  hook.ID,
+		PostID:     postID,
+		Event:      event,
+		Attempts:   int32(result.Attempts),
+		StatusCode: statusCode,
+		Error:      errorMessage,
+		Succeeded:  result.Err == nil,
+		CreatedAt:  time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to log webhook delivery: %v", err)
+	}
+
+	return nil
+}
+
+type webhookJob struct {
+	hook   database.Webhook
+	event  webhook.Event
+	postID uuid.UUID
+}
+
+// webhookQueue delivers webhooks in the background while aggregating, so slow
+// or failing receivers and their retries do not hold up the scraping.
+type webhookQueue struct {
+	state *state
+	jobs  chan webhookJob
+	done  chan struct{}
+}
+
+func startWebhookQueue(s *state) *webhookQueue {
+	queue := &webhookQueue{
+		state: s,
+		jobs:  make(chan webhookJob, webhookQueueSize),
+		done:  make(chan struct{}),
+	}
+
+	go func() {
+		defer close(queue.done)
+
+		for job := range queue.jobs {
+			result, err := deliverWebhook(s, job.hook, job.event, uuid.NullUUID{UUID: job.postID, Valid: true})
+			logWebhookDelivery(job, result, err)
+		}
+	}()
+
+	return queue
+}
+
+func logWebhookDelivery(job webhookJob, result webhook.Result, err error) {
+	logger := slog.With("webhook", job.hook.Name, "webhook_id", job.hook.ID, "post_id", job.postID)
+
+	switch {
+	case err != nil:
+		logger.Error("failed to deliver webhook", "error", err)
+	case result.Err != nil:
+		logger.Warn("webhook delivery failed", "status", result.StatusCode, "attempts", result.Attempts, "error", result.Err)
+	default:
+		logger.Debug("webhook delivered", "status", result.StatusCode, "attempts", result.Attempts)
+	}
+}
+
+// enqueue queues the delivery. When the queue is full the delivery is
+// dropped and logged as failed, so it shows up in "gator webhook log".
+func (queue *webhookQueue) enqueue(job webhookJob) {
+	select {
+	case queue.jobs <- job:
+	default:
+		result := webhook.Result{Err: ErrWebhookQueueFull}
+		err := recordWebhookDelivery(queue.state, job.hook, uuid.NullUUID{UUID: job.postID, Valid: true}, job.event.Event, result)
+		logWebhookDelivery(job, result, err)
+	}
+}
+
+// close waits for the queued deliveries to finish.
+func (queue *webhookQueue) close() {
+	close(queue.jobs)
+	<-queue.done
+}
+
+// notifyWebhooks sends the new post to the webhooks interested in it. It runs
+// after the rules so webhooks can match the tags they set.
+func notifyWebhooks(s *state, feed database.Feed, post database.Post) error {
+	hooks, err := s.database.GetWebhooksForPost(context.Background(), post.ID)
+	if err != nil {
+		return err
+	}
+
+	var publishedAt *time.Time
+	if post.PublishedAt.Valid {
+		publishedAt = &post.PublishedAt.Time
+	}
+
+	for _, match := range hooks {
+		hook := database.Webhook{
+			ID:     match.ID,
+			UserID: match.UserID,
+			Name:   match.Name,
+			Url:    match.Url,
+			Format: match.Format,
+			Secret: match.Secret,
+		}
+
+		tags := match.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+
+		event := webhook.Event{
+			Event:   webhook.EventPostCreated,
+			Webhook: match.Name,
+			User:    match.UserName,
+			SentAt:  time.Now(),
+			Post: webhook.Post{
+				ID:          post.ID.String(),
+				FeedID:      feed.ID.String(),
+				FeedName:    feed.Name,
+				FeedURL:     feed.Url,
+				Title:       post.Title,
+				URL:         post.Url,
+				Author:      post.Author,
+				Summary:     excerpt(post.Description),
+				Tags:        tags,
+				PublishedAt: publishedAt,
+			},
+		}
+
+		job := webhookJob{hook: hook, event: event, postID: post.ID}
+		if s.webhooks != nil {
+			s.webhooks.enqueue(job)
+			continue
+		}
+
+		result, err := deliverWebhook(s, job.hook, job.event, uuid.NullUUID{UUID: job.postID, Valid: true})
+		if err != nil {
+			return err
+		}
+		logWebhookDelivery(job, result, nil)
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fekete965/boot.dev-blog-aggregator/internal/rules"
+)
+
+func TestWebhookTagFilterMatchesRuleTags(t *testing.T) {
+	s := newTestState(t)
+	fixture := createTestFixture(t, s, "alice", 2)
+
+	err := handleWebhookAdd(s, command{
+		name: "webhook add",
+		args: []string{"releases", "https://hooks.example.com/releases", "--tag", " Go "},
+	}, fixture.user)
+	if err != nil {
+		t.Fatalf("webhook add failed: %v", err)
+	}
+
+	rule, err := rules.Parse(`if title = "Post 1" then tag GO`)
+	if err != nil {
+		t.Fatalf("failed to parse rule: %v", err)
+	}
+	tagged, untagged := fixture.posts[0], fixture.posts[1]
+	if err := applyRuleActions(s, fixture.user.ID, tagged.ID, rule.Actions); err != nil {
+		t.Fatalf("failed to apply rule: %v", err)
+	}
+
+	hooks, err := s.database.GetWebhooksForPost(context.Background(), tagged.ID)
+	if err != nil {
+		t.Fatalf("failed to get webhooks: %v", err)
+	}
+	if len(hooks) != 1 || hooks[0].Name != "releases" {
+		t.Fatalf("webhooks for the tagged post = %+v, want \"releases\"", hooks)
+	}
+	if hooks[0].Tag.String != "go" {
+		t.Errorf("stored tag = %q, want \"go\"", hooks[0].Tag.String)
+	}
+
+	hooks, err = s.database.GetWebhooksForPost(context.Background(), untagged.ID)
+	if err != nil {
+		t.Fatalf("failed to get webhooks: %v", err)
+	}
+	if len(hooks) != 0 {
+		t.Errorf("webhooks for the untagged post = %+v, want none", hooks)
+	}
+}